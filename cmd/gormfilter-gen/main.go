@@ -0,0 +1,438 @@
+// Command gormfilter-gen generates reflection-free Scope methods for filter structs from their filter tags.
+//
+// Usage, in the package of the filter structs:
+//
+//	//go:generate go run github.com/hicolin/gorm-filter/cmd/gormfilter-gen -type MockUserFilter
+//
+// For each type it writes a method
+//
+//	func (f MockUserFilter) Scope() func(*gorm.DB) *gorm.DB
+//
+// building the same conditions as filter.Filter(f) and applying them with filter.Conditions, so that
+// RequireScope, RequireAtLeast, SetLogger and OnApply apply to them too. Fields of basic types, pointers
+// and slices of them, time.Time and filter.Opt are supported with the =, !=, >, <, >=, <=, like, not_like,
+// starts_with, ends_with, rlike, in, not_in, is_null, not_null and raw operators and the column, table,
+// alias, use_zero, including kinds like use_zero:int|bool, empty_none, logic, not, sql and wildcard options.
+// Other fields are reported as errors, use filter.Filter for them. The settings changing how conditions
+// are built are not applied: SetMaxInSize, SetEmptyNone, SetDefaultTransforms, SetTablePrefix, SetTagKey,
+// SetNamingStrategy and the options of engines.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hicolin/gorm-filter/filter"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of filter struct types, required")
+	output    = flag.String("output", "", "output file, default <dir>/gormfilter_gen.go")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: gormfilter-gen -type T[,T...] [-output file] [dir]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	if *output == "" {
+		*output = filepath.Join(dir, "gormfilter_gen.go")
+	}
+
+	src, err := generate(dir, strings.Split(*typeNames, ","))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gormfilter-gen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "gormfilter-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the formatted source of the Scope methods of the types declared in the package in dir
+func generate(dir string, types []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%d packages in %s", len(pkgs), dir)
+	}
+
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+	structs := make(map[string]*ast.StructType)
+	for _, file := range pkg.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.TypeSpec); ok {
+				if st, ok := spec.Type.(*ast.StructType); ok {
+					structs[spec.Name.Name] = st
+				}
+			}
+			return true
+		})
+	}
+
+	g := generator{pkg: pkg.Name}
+	for _, name := range types {
+		name = strings.TrimSpace(name)
+		st, ok := structs[name]
+		if !ok {
+			return nil, fmt.Errorf("struct type %s not found in %s", name, dir)
+		}
+		if err := g.scope(name, st); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gormfilter-gen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg.Name)
+	if g.like {
+		buf.WriteString("\t\"strings\"\n\n")
+	}
+	buf.WriteString("\t\"github.com/hicolin/gorm-filter/filter\"\n\t\"gorm.io/gorm\"\n\t\"gorm.io/gorm/clause\"\n)\n")
+	if g.like {
+		buf.WriteString("\n// gormfilterLikeEscaper escapes the wildcards of like patterns\n")
+		buf.WriteString("var gormfilterLikeEscaper = strings.NewReplacer(\"!\", \"!!\", \"%\", \"!%\", \"_\", \"!_\")\n")
+	}
+	buf.Write(g.buf.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format: %w\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// generator writes the Scope methods
+type generator struct {
+	buf  bytes.Buffer
+	pkg  string // 包名, 用于 OnApply 报告的来源
+	like bool   // 是否使用了 like 转义
+}
+
+// scope writes the Scope method of the struct type name
+func (g *generator) scope(name string, st *ast.StructType) error {
+	var body bytes.Buffer
+	var count, ors int
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			unquoted, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(unquoted)
+		}
+		if len(field.Names) == 0 {
+			if tag.Get("filter") != "" {
+				return fmt.Errorf("embedded field %s is not supported", exprString(field.Type))
+			}
+			continue
+		}
+
+		for _, ident := range field.Names {
+			rule, ok, err := filter.FieldRule(reflect.StructField{Name: ident.Name, Tag: tag})
+			if err != nil {
+				return err
+			}
+			if !ok || !ident.IsExported() {
+				continue
+			}
+			if err := checkTag(tag.Get("filter"), rule); err != nil {
+				return fmt.Errorf("field %s: %w", ident.Name, err)
+			}
+
+			code, err := g.condition(rule, "f."+ident.Name, field.Type)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", ident.Name, err)
+			}
+			body.WriteString(code)
+			count++
+			if rule.Logic == filter.LogicOr {
+				ors++
+			}
+		}
+	}
+
+	fmt.Fprintf(&g.buf, "\n// Scope returns the filter scope of f, like filter.Filter(f) without reflection\n")
+	fmt.Fprintf(&g.buf, "func (f %s) Scope() func(*gorm.DB) *gorm.DB {\n\treturn func(db *gorm.DB) *gorm.DB {\n", name)
+	fmt.Fprintf(&g.buf, "\t\tands := make([]clause.Expression, 0, %d)\n", count)
+	orsArg := "nil"
+	if ors > 0 {
+		fmt.Fprintf(&g.buf, "\t\tors := make([]clause.Expression, 0, %d)\n", ors)
+		orsArg = "ors"
+	}
+	g.buf.Write(body.Bytes())
+	fmt.Fprintf(&g.buf, "\t\treturn filter.Conditions(%q, ands, %s)(db)\n\t}\n}\n", g.pkg+"."+name, orsArg)
+	return nil
+}
+
+// checkTag reports the tag options the generator does not support
+func checkTag(tag string, rule filter.Rule) error {
+	for _, part := range strings.Split(strings.Trim(tag, " ;,"), ";") {
+		if !strings.Contains(part, ":") {
+			return fmt.Errorf("%q fields are not supported", strings.TrimSpace(part))
+		}
+	}
+	switch {
+	case len(rule.Columns) > 0:
+		return fmt.Errorf("columns is not supported")
+	case rule.Group != "" || rule.GroupLogic != "":
+		return fmt.Errorf("groups are not supported")
+	case rule.Join != "" || rule.Rel != "":
+		return fmt.Errorf("join and rel are not supported")
+	case rule.Having:
+		return fmt.Errorf("having is not supported")
+	case len(rule.Transform) > 0 || len(rule.Validators) > 0 || rule.Map != nil || rule.Default != nil || rule.When != "":
+		return fmt.Errorf("transform, validate, map, default and when are not supported")
+	case rule.Layout != "" || rule.TZ != "" || rule.HalfOpen:
+		return fmt.Errorf("layout, tz and half_open are not supported")
+	case rule.Type == filter.TypeUUID:
+		return fmt.Errorf("type uuid is not supported")
+	}
+	return nil
+}
+
+// usesZero reports whether the zero kinds of rule, set like use_zero:int|bool, include the basic type or time.Time
+func usesZero(rule filter.Rule, basic string) bool {
+	kind := filter.TypeString
+	switch basic {
+	case "bool":
+		kind = filter.TypeBool
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		kind = filter.TypeInt
+	case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		kind = filter.TypeUint
+	case "float32", "float64":
+		kind = filter.TypeFloat
+	case "time.Time":
+		kind = filter.TypeTime
+	}
+	for _, k := range rule.ZeroKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldType is the supported form of a field type
+type fieldType struct {
+	form  string // basic, ptr, slice, time, ptr_time, opt
+	basic string // basic 类型名, 如 string, int64
+}
+
+// basicTypes are the supported basic types
+var basicTypes = map[string]bool{
+	"string": true, "bool": true, "byte": true, "rune": true, "float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+// typeOf returns the form of a field type expression
+func typeOf(expr ast.Expr) (fieldType, bool) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if basicTypes[t.Name] {
+			return fieldType{form: "basic", basic: t.Name}, true
+		}
+	case *ast.SelectorExpr:
+		if exprString(t) == "time.Time" {
+			return fieldType{form: "time"}, true
+		}
+	case *ast.StarExpr:
+		if elem, ok := typeOf(t.X); ok && elem.form == "basic" {
+			return fieldType{form: "ptr", basic: elem.basic}, true
+		}
+		if elem, ok := typeOf(t.X); ok && elem.form == "time" {
+			return fieldType{form: "ptr_time"}, true
+		}
+	case *ast.ArrayType:
+		if elem, ok := typeOf(t.Elt); ok && t.Len == nil && elem.form == "basic" {
+			return fieldType{form: "slice", basic: elem.basic}, true
+		}
+	case *ast.IndexExpr:
+		name := exprString(t.X)
+		if elem, ok := typeOf(t.Index); ok && (name == "Opt" || strings.HasSuffix(name, ".Opt")) && elem.form == "basic" {
+			return fieldType{form: "opt", basic: elem.basic}, true
+		}
+	}
+	return fieldType{}, false
+}
+
+// exprString returns the source of a type expression
+func exprString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return exprString(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprString(t.X)
+	case *ast.ArrayType:
+		return "[]" + exprString(t.Elt)
+	case *ast.IndexExpr:
+		return exprString(t.X) + "[" + exprString(t.Index) + "]"
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+// condition returns the code appending the condition of a field to ands or ors
+func (g *generator) condition(rule filter.Rule, field string, expr ast.Expr) (string, error) {
+	ft, ok := typeOf(expr)
+	if !ok {
+		return "", fmt.Errorf("type %s is not supported", exprString(expr))
+	}
+
+	// 非零值判断和取值, 与 Filter 一致: 指针、切片和 Opt 未设置时忽略, 其他类型 use_zero 时使用零值
+	var check, value string
+	switch ft.form {
+	case "basic":
+		value = field
+		switch {
+		case rule.UseZero || usesZero(rule, ft.basic):
+		case ft.basic == "string":
+			check = field + ` != ""`
+		case ft.basic == "bool":
+			check = field
+		default:
+			check = field + " != 0"
+		}
+	case "time":
+		value = field
+		if !rule.UseZero && !usesZero(rule, "time.Time") {
+			check = "!" + field + ".IsZero()"
+		}
+	case "ptr", "ptr_time":
+		check, value = field+" != nil", "*"+field
+	case "slice":
+		check, value = "len("+field+") > 0", field
+		switch {
+		case rule.UseZero && rule.Opt == filter.In: // 与 Filter 一样, nil 和空切片都不匹配任何行
+			check = ""
+		case rule.EmptyNone && rule.Opt == filter.In: // 空切片不匹配任何行, nil 切片忽略
+			check = field + " != nil"
+		}
+	case "opt":
+		check, value = field+".IsSet()", field+".Get()"
+	}
+
+	column := fmt.Sprintf("clause.Column{Name: %q}", rule.Name)
+	if rule.Column != "" {
+		column = fmt.Sprintf("clause.Column{Name: %q}", rule.Column)
+	}
+	if table := rule.Table; table != "" || rule.Alias != "" {
+		if rule.Alias != "" {
+			table = rule.Alias
+		}
+		column = strings.Replace(column, "{", fmt.Sprintf("{Table: %q, ", table), 1)
+	}
+
+	isSlice := ft.form == "slice"
+	isString := ft.basic == "string" && !isSlice
+	var cond, pre string
+	switch rule.Opt {
+	case "", filter.Eq, filter.Neq, "neq", filter.GT, filter.LT, filter.GTE, filter.LTE:
+		if isSlice {
+			return "", fmt.Errorf("%s requires a single value", rule.Opt)
+		}
+		names := map[string]string{"": "Eq", filter.Eq: "Eq", filter.Neq: "Neq", "neq": "Neq",
+			filter.GT: "Gt", filter.LT: "Lt", filter.GTE: "Gte", filter.LTE: "Lte"}
+		cond = fmt.Sprintf("clause.%s{Column: %s, Value: %s}", names[rule.Opt], column, value)
+	case filter.Like, filter.NotLike, filter.StartsWith, filter.EndsWith:
+		if !isString {
+			return "", fmt.Errorf("%s requires a string field", rule.Opt)
+		}
+		pattern, escape := value, " escape '!'"
+		if rule.Wildcard {
+			escape = ""
+		} else {
+			g.like = true
+			pattern = "gormfilterLikeEscaper.Replace(" + value + ")"
+		}
+		switch rule.Opt {
+		case filter.StartsWith:
+			pattern += ` + "%"`
+		case filter.EndsWith:
+			pattern = `"%" + ` + pattern
+		default:
+			pattern = `"%" + ` + pattern + ` + "%"`
+		}
+		sql := "? like ?"
+		if rule.Opt == filter.NotLike {
+			sql = "? not like ?"
+		}
+		cond = fmt.Sprintf("clause.Expr{SQL: %q, Vars: []interface{}{%s, %s}}", sql+escape, column, pattern)
+		if rule.Wildcard && rule.Opt != filter.NotLike { // 与 Filter 一样使用 clause.Like
+			cond = fmt.Sprintf("clause.Like{Column: %s, Value: %s}", column, pattern)
+		}
+	case filter.Rlike:
+		cond = fmt.Sprintf("clause.Expr{SQL: \"? rlike ?\", Vars: []interface{}{%s, %s}}", column, value)
+	case filter.In, filter.NotIn:
+		if !isSlice {
+			return "", fmt.Errorf("%s requires a slice field", rule.Opt)
+		}
+		pre = fmt.Sprintf("\t\t\tvalues := make([]interface{}, len(%s))\n\t\t\tfor i, v := range %s {\n\t\t\t\tvalues[i] = v\n\t\t\t}\n", field, field)
+		cond = fmt.Sprintf("clause.IN{Column: %s, Values: values}", column)
+		if rule.Opt == filter.NotIn {
+			cond = "clause.Not(" + cond + ")"
+		} else if rule.EmptyNone || rule.UseZero {
+			pre += "\t\t\tvar in clause.Expression = clause.Expr{SQL: \"1 = 0\"}\n\t\t\tif len(values) > 0 {\n\t\t\t\tin = " + cond + "\n\t\t\t}\n"
+			cond = "in"
+		}
+	case filter.IsNull, filter.NotNull:
+		name := "Eq"
+		if rule.Opt == filter.NotNull {
+			name = "Neq"
+		}
+		cond = fmt.Sprintf("clause.%s{Column: %s, Value: nil}", name, column)
+	case filter.Raw:
+		if rule.SQL == "" {
+			return "", fmt.Errorf("raw requires sql")
+		}
+		vars := strings.TrimSuffix(strings.Repeat(value+", ", strings.Count(rule.SQL, "?")), ", ")
+		cond = fmt.Sprintf("clause.Expr{SQL: %q, Vars: []interface{}{%s}}", rule.SQL, vars)
+	default:
+		return "", fmt.Errorf("operator %q is not supported", rule.Opt)
+	}
+
+	if rule.Not {
+		cond = fmt.Sprintf("clause.Expr{SQL: \"NOT (?)\", Vars: []interface{}{%s}}", cond)
+	}
+
+	list := "ands"
+	if rule.Logic == filter.LogicOr {
+		list = "ors"
+	}
+	code := pre + fmt.Sprintf("\t\t\t%s = append(%s, %s)\n", list, list, cond)
+	if check == "" {
+		return "\t\t{\n" + code + "\t\t}\n", nil
+	}
+	return "\t\tif " + check + " {\n" + code + "\t\t}\n", nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hicolin/gorm-filter/cmd/gormfilter-gen/testdata/golden"
+	"github.com/hicolin/gorm-filter/filter"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("testdata", "golden")
+	src, err := generate(dir, []string{"UserFilter", "OrderFilter"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "gormfilter_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("generated source differs from gormfilter_gen.go, run go generate in %s:\n%s", dir, src)
+	}
+}
+
+func TestScope(t *testing.T) {
+	age, day := 60, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var level filter.Opt[int]
+	level.Set(0)
+	cases := []struct {
+		name  string
+		dest  any
+		scope func(*gorm.DB) *gorm.DB
+	}{
+		{"empty", golden.UserFilter{}, golden.UserFilter{}.Scope()},
+		{"like", golden.UserFilter{Name: "j_o", Email: "jo", Phone: "42"}, golden.UserFilter{Name: "j_o", Email: "jo", Phone: "42"}.Scope()},
+		{"one or", golden.UserFilter{Email: "jo"}, golden.UserFilter{Email: "jo"}.Scope()},
+		{"compare", golden.UserFilter{Age: 18, MaxAge: &age, Active: true, Level: level, CreatedAt: day},
+			golden.UserFilter{Age: 18, MaxAge: &age, Active: true, Level: level, CreatedAt: day}.Scope()},
+		{"in", golden.UserFilter{IDs: []int{1, 2}, Roles: []string{"a", "b"}, Groups: []int{3}, Banned: true},
+			golden.UserFilter{IDs: []int{1, 2}, Roles: []string{"a", "b"}, Groups: []int{3}, Banned: true}.Scope()},
+		{"not raw", golden.UserFilter{Nickname: "j%", Score: 1.5}, golden.UserFilter{Nickname: "j%", Score: 1.5}.Scope()},
+		{"alias", golden.OrderFilter{Status: "paid", Tags: []int64{7}}, golden.OrderFilter{Status: "paid", Tags: []int64{7}}.Scope()},
+		{"empty none", golden.OrderFilter{Tags: []int64{}}, golden.OrderFilter{Tags: []int64{}}.Scope()},
+		{"use zero", golden.UserFilter{Groups: []int{}}, golden.UserFilter{Groups: []int{}}.Scope()},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			want := dryRunSQL(t, filter.Filter(tt.dest))
+			if got := dryRunSQL(t, tt.scope); got != want {
+				t.Errorf("Scope() = %s\nFilter() = %s", got, want)
+			}
+		})
+	}
+}
+
+func TestScopeRequireAtLeast(t *testing.T) {
+	filter.RequireAtLeast(1)
+	defer filter.RequireAtLeast(0)
+
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Table("orders").Scopes(golden.OrderFilter{}.Scope()).Find(&[]map[string]any{}).Error
+	if !errors.Is(err, filter.ErrUnfiltered) {
+		t.Errorf("error = %v, want %v", err, filter.ErrUnfiltered)
+	}
+}
+
+// dryRunSQL returns the SQL and vars of finding rows with the scope
+func dryRunSQL(t *testing.T, scope func(*gorm.DB) *gorm.DB) string {
+	t.Helper()
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := db.Table("users").Scopes(scope).Find(&[]map[string]any{}).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	return fmt.Sprint(stmt.SQL.String(), stmt.Vars)
+}
@@ -0,0 +1,32 @@
+// Package golden holds the filter structs of the generator tests, gormfilter_gen.go is generated from them
+package golden
+
+import (
+	"time"
+
+	"github.com/hicolin/gorm-filter/filter"
+)
+
+//go:generate go run github.com/hicolin/gorm-filter/cmd/gormfilter-gen -type UserFilter,OrderFilter
+
+type UserFilter struct {
+	Name      string          `json:"name" filter:"opt:like"`
+	Email     string          `json:"email" filter:"opt:starts_with;logic:or"`
+	Phone     string          `json:"phone" filter:"opt:ends_with;logic:or"`
+	Age       int             `json:"age" filter:"opt:>="`
+	MaxAge    *int            `json:"max_age" filter:"column:age;opt:<="`
+	Active    bool            `json:"active" filter:"use_zero:bool"`
+	Level     filter.Opt[int] `json:"level" filter:"opt:="`
+	IDs       []int           `json:"ids" filter:"column:id;opt:in"`
+	Roles     []string        `json:"roles" filter:"column:role;opt:not_in"`
+	Groups    []int           `json:"groups" filter:"column:group_id;opt:in;use_zero:true"`
+	Banned    bool            `json:"banned" filter:"column:banned_at;opt:not_null"`
+	Nickname  string          `json:"nickname" filter:"opt:like;wildcard:true;not:true"`
+	CreatedAt time.Time       `json:"created_at" filter:"table:users;opt:>"`
+	Score     float64         `json:"score" filter:"opt:raw;sql:score * 2 > ?"`
+}
+
+type OrderFilter struct {
+	Status string  `json:"status" filter:"alias:o;opt:!="`
+	Tags   []int64 `json:"tags" filter:"column:tag_id;opt:in;empty_none:true"`
+}
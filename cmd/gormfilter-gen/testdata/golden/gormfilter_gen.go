@@ -0,0 +1,103 @@
+// Code generated by gormfilter-gen; DO NOT EDIT.
+
+package golden
+
+import (
+	"strings"
+
+	"github.com/hicolin/gorm-filter/filter"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormfilterLikeEscaper escapes the wildcards of like patterns
+var gormfilterLikeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// Scope returns the filter scope of f, like filter.Filter(f) without reflection
+func (f UserFilter) Scope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		ands := make([]clause.Expression, 0, 14)
+		ors := make([]clause.Expression, 0, 2)
+		if f.Name != "" {
+			ands = append(ands, clause.Expr{SQL: "? like ? escape '!'", Vars: []interface{}{clause.Column{Name: "name"}, "%" + gormfilterLikeEscaper.Replace(f.Name) + "%"}})
+		}
+		if f.Email != "" {
+			ors = append(ors, clause.Expr{SQL: "? like ? escape '!'", Vars: []interface{}{clause.Column{Name: "email"}, gormfilterLikeEscaper.Replace(f.Email) + "%"}})
+		}
+		if f.Phone != "" {
+			ors = append(ors, clause.Expr{SQL: "? like ? escape '!'", Vars: []interface{}{clause.Column{Name: "phone"}, "%" + gormfilterLikeEscaper.Replace(f.Phone)}})
+		}
+		if f.Age != 0 {
+			ands = append(ands, clause.Gte{Column: clause.Column{Name: "age"}, Value: f.Age})
+		}
+		if f.MaxAge != nil {
+			ands = append(ands, clause.Lte{Column: clause.Column{Name: "age"}, Value: *f.MaxAge})
+		}
+		{
+			ands = append(ands, clause.Eq{Column: clause.Column{Name: "active"}, Value: f.Active})
+		}
+		if f.Level.IsSet() {
+			ands = append(ands, clause.Eq{Column: clause.Column{Name: "level"}, Value: f.Level.Get()})
+		}
+		if len(f.IDs) > 0 {
+			values := make([]interface{}, len(f.IDs))
+			for i, v := range f.IDs {
+				values[i] = v
+			}
+			ands = append(ands, clause.IN{Column: clause.Column{Name: "id"}, Values: values})
+		}
+		if len(f.Roles) > 0 {
+			values := make([]interface{}, len(f.Roles))
+			for i, v := range f.Roles {
+				values[i] = v
+			}
+			ands = append(ands, clause.Not(clause.IN{Column: clause.Column{Name: "role"}, Values: values}))
+		}
+		{
+			values := make([]interface{}, len(f.Groups))
+			for i, v := range f.Groups {
+				values[i] = v
+			}
+			var in clause.Expression = clause.Expr{SQL: "1 = 0"}
+			if len(values) > 0 {
+				in = clause.IN{Column: clause.Column{Name: "group_id"}, Values: values}
+			}
+			ands = append(ands, in)
+		}
+		if f.Banned {
+			ands = append(ands, clause.Neq{Column: clause.Column{Name: "banned_at"}, Value: nil})
+		}
+		if f.Nickname != "" {
+			ands = append(ands, clause.Expr{SQL: "NOT (?)", Vars: []interface{}{clause.Like{Column: clause.Column{Name: "nickname"}, Value: "%" + f.Nickname + "%"}}})
+		}
+		if !f.CreatedAt.IsZero() {
+			ands = append(ands, clause.Gt{Column: clause.Column{Table: "users", Name: "created_at"}, Value: f.CreatedAt})
+		}
+		if f.Score != 0 {
+			ands = append(ands, clause.Expr{SQL: "score * 2 > ?", Vars: []interface{}{f.Score}})
+		}
+		return filter.Conditions("golden.UserFilter", ands, ors)(db)
+	}
+}
+
+// Scope returns the filter scope of f, like filter.Filter(f) without reflection
+func (f OrderFilter) Scope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		ands := make([]clause.Expression, 0, 2)
+		if f.Status != "" {
+			ands = append(ands, clause.Neq{Column: clause.Column{Table: "o", Name: "status"}, Value: f.Status})
+		}
+		if f.Tags != nil {
+			values := make([]interface{}, len(f.Tags))
+			for i, v := range f.Tags {
+				values[i] = v
+			}
+			var in clause.Expression = clause.Expr{SQL: "1 = 0"}
+			if len(values) > 0 {
+				in = clause.IN{Column: clause.Column{Name: "tag_id"}, Values: values}
+			}
+			ands = append(ands, in)
+		}
+		return filter.Conditions("golden.OrderFilter", ands, nil)(db)
+	}
+}
@@ -0,0 +1,30 @@
+package filter
+
+import (
+	"gorm.io/gorm"
+)
+
+// GinContext is the part of *gin.Context used by BindGin, so the package does not depend on gin
+type GinContext interface {
+	ShouldBind(obj any) error
+}
+
+// BindGin binds the query or body of a gin request into the filter struct dest with c.ShouldBind,
+// checks its filter tags and values, and returns the Filter scope of dest. dest must be a pointer
+func BindGin(c GinContext, dest any) (func(*gorm.DB) *gorm.DB, error) {
+	if err := c.ShouldBind(dest); err != nil {
+		return nil, err
+	}
+	return bindScope(dest)
+}
+
+// bindScope checks the filter tags and values of a bound dest and returns its Filter scope
+func bindScope(dest any) (func(*gorm.DB) *gorm.DB, error) {
+	if err := Validate(dest); err != nil {
+		return nil, err
+	}
+	if _, err := std.buildFilter(nil, dest); err != nil { // 提前返回无效的值, 如格式错误的日期
+		return nil, err
+	}
+	return FilterE(dest), nil
+}
@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// And returns a scope adding the WHERE conditions of the scopes, such as those of Filter and Search, joined with AND.
+// Joins added by the scopes are kept, their orders, pagination, selected fields and HAVING conditions are ignored
+func And(scopes ...func(*gorm.DB) *gorm.DB) func(*gorm.DB) *gorm.DB {
+	return combineScopes(LogicAnd, scopes)
+}
+
+// Or returns a scope adding the WHERE conditions of the scopes joined with OR, each scope as one group,
+// e.g. Or(Filter(userFilter), Filter(fallbackFilter)). Scopes adding no conditions are left out,
+// the rest is like And
+func Or(scopes ...func(*gorm.DB) *gorm.DB) func(*gorm.DB) *gorm.DB {
+	return combineScopes(LogicOr, scopes)
+}
+
+// combineScopes returns a scope joining the WHERE conditions of the scopes with logic
+func combineScopes(logic string, scopes []func(*gorm.DB) *gorm.DB) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if db.Error != nil {
+			return db
+		}
+		exprs := make([]clause.Expression, 0, len(scopes))
+		for _, scope := range scopes {
+			expr, err := scopeWhere(db, scope)
+			if err != nil {
+				_ = db.AddError(err)
+				return db
+			}
+			if expr != nil {
+				exprs = append(exprs, expr)
+			}
+		}
+		if expr, ok, _ := joinExprs(exprs, logic); ok {
+			addWhere(db, expr)
+		}
+		return db
+	}
+}
+
+// scopeWhere applies scope to a copy of the statement of db without its WHERE clause and returns the conditions
+// added by scope, nil if none. The joins added by scope are added to db
+func scopeWhere(db *gorm.DB, scope func(*gorm.DB) *gorm.DB) (clause.Expression, error) {
+	tx := db.Session(&gorm.Session{}).Clauses() // 复制 Statement, 保留 Model、Table 和 context
+	delete(tx.Statement.Clauses, "WHERE")
+	joins := len(tx.Statement.Joins)
+	if tx = scope(tx); tx.Error != nil {
+		return nil, tx.Error
+	}
+	db.Statement.Joins = append(db.Statement.Joins, tx.Statement.Joins[joins:]...)
+
+	c, ok := tx.Statement.Clauses["WHERE"]
+	if !ok {
+		return nil, nil
+	}
+	where, ok := c.Expression.(clause.Where)
+	if !ok || len(where.Exprs) == 0 {
+		return nil, nil
+	}
+	return clause.AndConditions{Exprs: where.Exprs}, nil
+}
@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cond is a condition tree built in code instead of from struct tags, such as
+// C("age", GTE, 30).And(C("name", Like, "jo").Or(C("city", Eq, "NYC"))). Its conditions are generated like
+// those of Search, a tree without conditions adds none
+type Cond struct {
+	rule  Rule   // 叶子条件的规则
+	value any    // 叶子条件的值
+	logic string // 子条件的连接方式, 为空时为叶子条件
+	conds []Cond
+	not   bool
+}
+
+// C returns the condition of the column name with the operator and value, zero values are used
+// like C("active", Eq, false) and nil compares with null
+func C(name, opt string, value any) Cond {
+	return Cond{rule: Rule{Name: name, Opt: opt, UseZero: true}, value: value}
+}
+
+// RuleCond returns the condition of rule with the value, for the options of rules such as Table and Columns,
+// zero values are skipped unless the rule has UseZero
+func RuleCond(rule Rule, value any) Cond {
+	return Cond{rule: rule, value: value}
+}
+
+// And returns the condition joining c and conds with AND
+func (c Cond) And(conds ...Cond) Cond {
+	return c.join(LogicAnd, conds)
+}
+
+// Or returns the condition joining c and conds with OR
+func (c Cond) Or(conds ...Cond) Cond {
+	return c.join(LogicOr, conds)
+}
+
+// Not returns the negation of c
+func (c Cond) Not() Cond {
+	c.not = !c.not
+	return c
+}
+
+// join returns the condition joining c and conds with logic, flattening c if it is joined the same way
+func (c Cond) join(logic string, conds []Cond) Cond {
+	if c.logic == logic && !c.not {
+		c.conds = append(c.conds[:len(c.conds):len(c.conds)], conds...) // 不修改 c 共享的子条件
+		return c
+	}
+	return Cond{logic: logic, conds: append([]Cond{c}, conds...)}
+}
+
+// FromCond applies the condition tree c, it panics on invalid rules or values
+func FromCond(c Cond) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildCond(db, c)
+	}, false)
+}
+
+// FromCondE is like FromCond but adds errors to db
+func FromCondE(c Cond) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildCond(db, c)
+	}, true)
+}
+
+// buildCond builds the condition of the condition tree c
+func buildCond(db *gorm.DB, c Cond) (res result, err error) {
+	res.source = "cond"
+	expr, ok, err := c.expr(db)
+	if err != nil {
+		return res, err
+	}
+	if ok {
+		res.conditions = append(res.conditions, condition{expr: expr})
+	}
+	return res, nil
+}
+
+// expr returns the expression of c, ok is false if it has no conditions
+func (c Cond) expr(db *gorm.DB) (clause.Expression, bool, error) {
+	var expr clause.Expression
+	if c.logic == "" {
+		cond, ok, err := anyCondition(db, c.rule, c.value)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		expr = cond.expr
+	} else {
+		exprs := make([]clause.Expression, 0, len(c.conds))
+		for _, sub := range c.conds {
+			e, ok, err := sub.expr(db)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				exprs = append(exprs, e)
+			}
+		}
+		e, ok, err := joinExprs(exprs, c.logic)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		expr = e
+	}
+
+	if c.not {
+		return not(expr), true, nil
+	}
+	return expr, true, nil
+}
@@ -0,0 +1,47 @@
+package filter
+
+import (
+	"database/sql/driver"
+	"math/big"
+	"reflect"
+)
+
+// decimalValue returns the number of a decimal type such as github.com/shopspring/decimal.Decimal, a struct
+// implementing driver.Valuer whose value is a number or a numeric string. Its comparisons bind the Valuer itself,
+// the number is only used to detect zero values and to validate them
+func decimalValue(rfVal reflect.Value) (*big.Rat, bool) {
+	if !rfVal.IsValid() || rfVal.Kind() != reflect.Struct {
+		return nil, false
+	}
+	valuer, ok := rfVal.Interface().(driver.Valuer)
+	if !ok {
+		return nil, false
+	}
+	v, err := valuer.Value()
+	if err != nil {
+		return nil, false
+	}
+	switch v := v.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case float64:
+		if r := new(big.Rat); r.SetFloat64(v) != nil {
+			return r, true
+		}
+	case string:
+		return new(big.Rat).SetString(v)
+	case []byte:
+		return new(big.Rat).SetString(string(v))
+	}
+	return nil, false
+}
+
+// zeroValue reports whether rfVal is a zero value, decimals equal to 0 like decimal.NewFromInt(0) are zero
+// even though their struct fields are set
+func zeroValue(rfVal reflect.Value) bool {
+	if rfVal.IsZero() {
+		return true
+	}
+	r, ok := decimalValue(rfVal)
+	return ok && r.Sign() == 0
+}
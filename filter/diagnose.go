@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// reasons of SkippedField
+const (
+	SkipNoTag           = "no filter tag"        // 结构体字段没有 filter 标签或为 filter:"-"
+	SkipNoField         = "no field"             // Search 的规则没有同名字段, 如字段缺少 json 标签
+	SkipZero            = "zero value"           // 零值、nil 指针或空切片, 且未设置 use_zero
+	SkipNull            = "null value"           // 未设置的 Opt、为 null 的 sql.Null* 或 nil 子查询
+	SkipTransform       = "dropped by transform" // 变换后为空, 如 trim 后的空字符串
+	SkipWhen            = "when does not hold"
+	SkipNilStruct       = "nil embedded struct"
+	SkipUnknownOperator = "unknown operator"
+	SkipNoCondition     = "no condition" // 运算符没有生成条件, 如 date_range 的两个边界都为空
+)
+
+// SkippedField is a struct field or Search rule that added no condition to the query
+type SkippedField struct {
+	Field  string // 结构体字段名, 嵌套结构体的字段如 Profile.City, Search 的规则为空
+	Name   string // 规则名
+	Reason string // 跳过的原因, 如 SkipZero
+}
+
+// skippedSetting is the gorm setting holding the skipped fields recorded by Diagnose
+const skippedSetting = "gorm-filter:skipped"
+
+// Diagnose is a scope recording the fields and rules skipped by the filters applied after it,
+// e.g. tx := db.Scopes(filter.Diagnose, filter.Filter(f)).Find(&users), then filter.Skipped(tx)
+func Diagnose(db *gorm.DB) *gorm.DB {
+	db.Statement.Settings.Store(skippedSetting, &[]SkippedField{})
+	return db
+}
+
+// Skipped returns the fields and rules skipped by the filters of the query since Diagnose, nil without Diagnose
+func Skipped(db *gorm.DB) []SkippedField {
+	if skipped := skippedFields(db); skipped != nil {
+		return *skipped
+	}
+	return nil
+}
+
+// ExplainSkipped returns the fields Filter would skip for the dest struct, without a database session
+func ExplainSkipped(dest any) ([]SkippedField, error) {
+	res, err := std.buildFilter(nil, dest)
+	if err != nil {
+		return nil, err
+	}
+	return append(std.untaggedFields(reflect.TypeOf(dest)), res.skipped...), nil
+}
+
+// skippedFields returns the skipped fields recorded for the query of db, nil without Diagnose
+func skippedFields(db *gorm.DB) *[]SkippedField {
+	if db == nil || db.Statement == nil {
+		return nil
+	}
+	if v, ok := db.Statement.Settings.Load(skippedSetting); ok {
+		return v.(*[]SkippedField)
+	}
+	return nil
+}
+
+// untaggedFields returns the fields of the struct type rt, or of its elements, without filter tags,
+// joined structs are not included
+func (e *Engine) untaggedFields(rt reflect.Type) []SkippedField {
+	if rt == nil {
+		return nil
+	}
+	if rt = indirectType(rt); rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array { // 切片的元素字段相同, 只报告一次
+		rt = indirectType(rt.Elem())
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil
+	}
+	var skipped []SkippedField
+	for _, field := range reflect.VisibleFields(rt) {
+		if !field.IsExported() || field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			continue
+		}
+		if tag := strings.Trim(field.Tag.Get(e.tagKey), " ;,"); tag == "" || tag == "-" {
+			skipped = append(skipped, SkippedField{Field: field.Name, Name: e.fieldName(field), Reason: SkipNoTag})
+		}
+	}
+	return skipped
+}
+
+// skipReason returns why valueCondition added no condition for the value of rule
+func skipReason(rule Rule, rfVal reflect.Value) string {
+	if _, ok := lookupOperator(rule.Opt); rule.Opt != "" && !builtinOperators[rule.Opt] && !ok {
+		return SkipUnknownOperator
+	}
+	if sub, ok := subquery(rfVal); ok {
+		if sub == nil {
+			return SkipNull
+		}
+		return SkipNoCondition
+	}
+	v, valid, nullable := nullableValue(rfVal)
+	if nullable {
+		if !valid {
+			return SkipNull
+		}
+		rfVal = v
+	}
+	if hasTransforms(rule) {
+		if v, err := transformValue(rule, rfVal); err == nil {
+			if !v.IsValid() {
+				return SkipTransform
+			}
+			rfVal = v
+		}
+	}
+	emptySlice := rfVal.Kind() == reflect.Slice && rfVal.Len() == 0
+	if !nullable && (zeroValue(rfVal) || emptySlice) && !usesZero(rule, rfVal) {
+		return SkipZero
+	}
+	return SkipNoCondition
+}
@@ -0,0 +1,232 @@
+package filter
+
+import (
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Engine applies filters with its own tag key, naming strategy, default operator, zero values, strictness,
+// dialect and table prefix, e.g. for libraries sharing a process with differently configured filters.
+// Only these options are its own: the other package settings, such as SetPageSize, SetMaxInSize, SetEmptyNone,
+// SetDateLocation, SetMultiSearchTokens, RequireScope and RequireAtLeast, apply to every engine,
+// and FindPage, Count and Keyset use the package engine
+type Engine struct {
+	tagKey      string       // 结构体标签名
+	namer       schema.Namer // 推导没有 json 名称和 gorm 列名的字段的列名
+	opt         string       // 未指定 opt 的规则使用的运算符, 为空时使用 =
+	useZero     bool         // 是否对所有规则使用零值
+	zeroKinds   []string     // 未设置 use_zero 的规则使用零值的值类型
+	strict      bool         // 是否报告未知的标签键和无效的规则, 而不是忽略
+	dialect     string       // 方言特定运算符使用的方言, 为空时使用数据库的方言
+	tablePrefix string       // 规则的表名前缀, 如 app_ 或 analytics.
+
+	cache sync.Map // 结构体类型的规则缓存, reflect.Type -> []fieldRule
+}
+
+// Option configures an Engine
+type Option func(*Engine)
+
+// WithTagKey sets the struct tag key holding the filter rules, "filter" by default
+func WithTagKey(key string) Option {
+	return func(e *Engine) { e.tagKey = key }
+}
+
+// WithNamingStrategy sets the naming strategy deriving the column of fields without json names or gorm columns,
+// gorm's schema.NamingStrategy by default
+func WithNamingStrategy(n schema.Namer) Option {
+	return func(e *Engine) { e.namer = n }
+}
+
+// WithDefaultOpt sets the operator of rules without opt, = by default
+func WithDefaultOpt(opt string) Option {
+	return func(e *Engine) { e.opt = opt }
+}
+
+// WithUseZero makes every rule use zero values, as if tagged use_zero:true
+func WithUseZero(useZero bool) Option {
+	return func(e *Engine) { e.useZero = useZero }
+}
+
+// WithZeroKinds makes the rules without use_zero use the zero values of the kinds, as if tagged like use_zero:int|bool,
+// e.g. TypeInt and TypeBool to filter on 0 and false but still skip empty strings
+func WithZeroKinds(kinds ...string) Option {
+	return func(e *Engine) { e.zeroKinds = kinds }
+}
+
+// WithStrict makes Filter report unknown tag keys and invalid tag rules, such as unknown operators,
+// like Validate instead of ignoring them
+func WithStrict(strict bool) Option {
+	return func(e *Engine) { e.strict = strict }
+}
+
+// WithDialect sets the dialect deciding the dialect specific operators, such as ilike and regexp,
+// e.g. postgres for a postgres compatible database whose dialector has another name
+func WithDialect(name string) Option {
+	return func(e *Engine) { e.dialect = name }
+}
+
+// WithTablePrefix sets the prefix of the tables of rules, such as app_ or a schema like analytics.
+func WithTablePrefix(prefix string) Option {
+	return func(e *Engine) { e.tablePrefix = prefix }
+}
+
+// std is the engine of the package level functions, configured by SetTagKey, SetNamingStrategy and SetTablePrefix
+var std = New()
+
+// New returns an engine configured by opts, its methods mirror the package level functions
+func New(opts ...Option) *Engine {
+	e := &Engine{tagKey: "filter", namer: schema.NamingStrategy{}}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Filter is like the package level Filter with the options of e
+func (e *Engine) Filter(dest any) func(*gorm.DB) *gorm.DB {
+	return e.scope(func(db *gorm.DB) (result, error) {
+		return e.buildFilter(db, dest)
+	}, false)
+}
+
+// FilterE is like the package level FilterE with the options of e
+func (e *Engine) FilterE(dest any) func(*gorm.DB) *gorm.DB {
+	return e.scope(func(db *gorm.DB) (result, error) {
+		return e.buildFilter(db, dest)
+	}, true)
+}
+
+// FilterAny is like the package level FilterAny with the options of e
+func (e *Engine) FilterAny(dest any) func(*gorm.DB) *gorm.DB {
+	return e.scope(func(db *gorm.DB) (result, error) {
+		return anyOf(e.buildFilter(db, dest))
+	}, false)
+}
+
+// FilterAnyE is like the package level FilterAnyE with the options of e
+func (e *Engine) FilterAnyE(dest any) func(*gorm.DB) *gorm.DB {
+	return e.scope(func(db *gorm.DB) (result, error) {
+		return anyOf(e.buildFilter(db, dest))
+	}, true)
+}
+
+// Search is like the package level Search with the options of e
+func (e *Engine) Search(rules []Rule, dest any) func(*gorm.DB) *gorm.DB {
+	return e.scope(func(db *gorm.DB) (result, error) {
+		return e.buildSearch(db, rules, dest)
+	}, false)
+}
+
+// SearchE is like the package level SearchE with the options of e
+func (e *Engine) SearchE(rules []Rule, dest any) func(*gorm.DB) *gorm.DB {
+	return e.scope(func(db *gorm.DB) (result, error) {
+		return e.buildSearch(db, rules, dest)
+	}, true)
+}
+
+// MultiSearch is like the package level MultiSearch with the options of e
+func (e *Engine) MultiSearch(rules []Rule, dest string) func(*gorm.DB) *gorm.DB {
+	return e.scope(func(db *gorm.DB) (result, error) {
+		return e.buildMultiSearch(db, rules, dest)
+	}, false)
+}
+
+// MultiSearchE is like the package level MultiSearchE with the options of e
+func (e *Engine) MultiSearchE(rules []Rule, dest string) func(*gorm.DB) *gorm.DB {
+	return e.scope(func(db *gorm.DB) (result, error) {
+		return e.buildMultiSearch(db, rules, dest)
+	}, true)
+}
+
+// gorm settings holding the options of engines read while building conditions
+const (
+	dialectSetting     = "gorm-filter:dialect"      // WithDialect
+	tablePrefixSetting = "gorm-filter:table_prefix" // WithTablePrefix, std 的前缀不保存
+)
+
+// scope is like the package level scope with the dialect and table prefix of e,
+// which are stored in the statement while the scope is applied and then restored for the next scopes
+func (e *Engine) scope(build func(db *gorm.DB) (result, error), addError bool) func(*gorm.DB) *gorm.DB {
+	apply := scope(build, addError)
+	return func(db *gorm.DB) *gorm.DB {
+		defer e.session(db)()
+		return apply(db)
+	}
+}
+
+// session stores the dialect and table prefix of e in the statement of db for dialect and prefixTable,
+// the returned function restores the previous settings
+func (e *Engine) session(db *gorm.DB) (restore func()) {
+	settings := &db.Statement.Settings
+	dialect, hasDialect := settings.Load(dialectSetting)
+	prefix, hasPrefix := settings.Load(tablePrefixSetting)
+	if e.dialect != "" {
+		settings.Store(dialectSetting, e.dialect)
+	}
+	if e != std {
+		settings.Store(tablePrefixSetting, e.tablePrefix)
+	}
+	return func() {
+		restoreSetting(settings, dialectSetting, dialect, hasDialect)
+		restoreSetting(settings, tablePrefixSetting, prefix, hasPrefix)
+	}
+}
+
+// restoreSetting restores the setting key to value, or deletes it if it was not set
+func restoreSetting(settings *sync.Map, key string, value any, ok bool) {
+	if ok {
+		settings.Store(key, value)
+	} else {
+		settings.Delete(key)
+	}
+}
+
+// rule applies the default operator, use_zero and zero kinds of e to rule
+func (e *Engine) rule(rule Rule) Rule {
+	if rule.Opt == "" && rule.special == "" {
+		rule.Opt = e.opt
+	}
+	if e.useZero {
+		rule.UseZero = true
+	}
+	if len(rule.ZeroKinds) == 0 && !rule.UseZero {
+		rule.ZeroKinds = e.zeroKinds
+	}
+	return rule
+}
+
+// rules returns the rules with the defaults of e, or rules itself if e has none
+func (e *Engine) rules(rules []Rule) []Rule {
+	if e.opt == "" && !e.useZero && len(e.zeroKinds) == 0 {
+		return rules
+	}
+	result := make([]Rule, len(rules))
+	for i, rule := range rules {
+		result[i] = e.rule(rule)
+	}
+	return result
+}
+
+// fieldRules returns the cached rules of the struct type rt
+func (e *Engine) fieldRules(rt reflect.Type) ([]fieldRule, error) {
+	if rules, ok := e.cache.Load(rt); ok {
+		return rules.([]fieldRule), nil
+	}
+	rules, err := e.parseFieldRules(rt)
+	if err != nil { // 错误不缓存
+		return nil, err
+	}
+	e.cache.Store(rt, rules)
+	return rules, nil
+}
+
+// clearCache drops the cached rules after changing how tags are parsed
+func (e *Engine) clearCache() {
+	e.cache.Range(func(key, _ any) bool {
+		e.cache.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,174 @@
+package filter
+
+import (
+	"database/sql/driver"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Explain returns the condition and parameters Filter would generate for the dest struct,
+// without a database session. Column names are not quoted, dialect specific operators use the MySQL form
+// and joins of nested structs are not included. Conditions of having rules follow "HAVING ", subqueries
+// are written in the form of their own database.
+func Explain(dest any) (string, []any, error) {
+	return explain(std.buildFilter(nil, dest))
+}
+
+// ExplainSearch returns the condition and parameters Search would generate
+func ExplainSearch(rules []Rule, dest any) (string, []any, error) {
+	return explain(std.buildSearch(nil, rules, dest))
+}
+
+// ExplainMultiSearch returns the condition and parameters MultiSearch would generate
+func ExplainMultiSearch(rules []Rule, dest string) (string, []any, error) {
+	return explain(std.buildMultiSearch(nil, rules, dest))
+}
+
+// ExplainMap returns the condition and parameters FilterMap would generate
+func ExplainMap(rules []Rule, values map[string]any) (string, []any, error) {
+	return explain(buildMap(nil, rules, values))
+}
+
+// ExplainQuery returns the condition and parameters FromQuery would generate
+func ExplainQuery(rules []Rule, q url.Values) (string, []any, error) {
+	return explain(buildQuery(nil, rules, q))
+}
+
+// ExplainMongo returns the condition and parameters FromMongo would generate
+func ExplainMongo(rules []Rule, data []byte) (string, []any, error) {
+	return explain(buildMongo(nil, rules, data))
+}
+
+// ExplainRSQL returns the condition and parameters FromRSQL would generate
+func ExplainRSQL(rules []Rule, filter string) (string, []any, error) {
+	return explain(buildRSQL(nil, rules, filter))
+}
+
+// ExplainOData returns the condition and parameters FromOData would generate
+func ExplainOData(rules []Rule, filter string) (string, []any, error) {
+	return explain(buildOData(nil, rules, filter))
+}
+
+// ExplainExpr returns the condition and parameters FromExpr would generate
+func ExplainExpr(rules []Rule, expr string) (string, []any, error) {
+	return explain(buildExpr(nil, rules, expr))
+}
+
+// ExplainCond returns the condition and parameters FromCond would generate
+func ExplainCond(c Cond) (string, []any, error) {
+	return explain(buildCond(nil, c))
+}
+
+// ExplainJSONAPI returns the condition and parameters FromJSONAPI would generate
+func ExplainJSONAPI(rules []Rule, q url.Values) (string, []any, error) {
+	return explain(buildJSONAPI(nil, rules, q))
+}
+
+// ExplainGraphQL returns the condition and parameters FromGraphQL would generate
+func ExplainGraphQL(dest any, input map[string]any) (string, []any, error) {
+	return explain(buildGraphQL(nil, dest, input))
+}
+
+// explain builds the joined conditions the same way a where clause does
+func explain(res result, err error) (string, []any, error) {
+	if err != nil {
+		return "", nil, err
+	}
+	if len(res.conditions) == 0 {
+		return "", nil, nil
+	}
+
+	var builder explainBuilder
+	where, having := splitHaving(res.conditions)
+	if len(where) > 0 {
+		clause.Where{Exprs: []clause.Expression{joinConditions(where)}}.Build(&builder)
+	}
+	if len(having) > 0 {
+		if len(where) > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString("HAVING ")
+		clause.Where{Exprs: []clause.Expression{joinConditions(having)}}.Build(&builder)
+	}
+
+	return builder.String(), builder.vars, builder.err
+}
+
+// explainBuilder is a clause.Builder writing unquoted names and collecting the vars
+type explainBuilder struct {
+	strings.Builder
+	vars []any
+	err  error
+}
+
+func (b *explainBuilder) WriteQuoted(field interface{}) {
+	switch v := field.(type) {
+	case clause.Column:
+		if v.Table != "" && v.Table != clause.CurrentTable {
+			b.WriteString(v.Table + ".")
+		}
+		b.WriteString(v.Name)
+	case clause.Table:
+		b.WriteString(v.Name)
+		if v.Alias != "" {
+			b.WriteString(" " + v.Alias)
+		}
+	case string:
+		b.WriteString(v)
+	}
+}
+
+func (b *explainBuilder) AddVar(writer clause.Writer, vars ...interface{}) {
+	for idx, v := range vars {
+		if idx > 0 {
+			writer.WriteByte(',')
+		}
+
+		switch v := v.(type) {
+		case clause.Column, clause.Table:
+			b.WriteQuoted(v)
+		case clause.Expression:
+			v.Build(b)
+		case *gorm.DB: // 子查询, 使用其数据库方言生成
+			stmt := v.Session(&gorm.Session{DryRun: true}).Find(&[]map[string]any{}).Statement
+			if stmt.Error != nil {
+				_ = b.AddError(stmt.Error)
+				continue
+			}
+			writer.WriteString(stmt.SQL.String())
+			b.vars = append(b.vars, stmt.Vars...)
+		case driver.Valuer, []byte:
+			writer.WriteByte('?')
+			b.vars = append(b.vars, v)
+		default:
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+				if rv.Len() == 0 {
+					writer.WriteString("(NULL)")
+					continue
+				}
+				writer.WriteByte('(')
+				for i := 0; i < rv.Len(); i++ {
+					if i > 0 {
+						writer.WriteByte(',')
+					}
+					b.AddVar(writer, rv.Index(i).Interface())
+				}
+				writer.WriteByte(')')
+				continue
+			}
+			writer.WriteByte('?')
+			b.vars = append(b.vars, v)
+		}
+	}
+}
+
+func (b *explainBuilder) AddError(err error) error {
+	if b.err == nil {
+		b.err = err
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FromExpr applies a filter expression typed by users, such as age > 30 and (name like "jo" or city = "NYC").
+// It supports the comparisons =, !=, <>, >, >=, <, <=, like, not like, in (...), not in (...), between ... and ...,
+// is null and is not null, any operator name such as starts_with, and, or, not and parentheses. Keywords are
+// case insensitive, strings are quoted with " or ' and escaped with a backslash. Fields must be rule names and
+// the operators must be allowed by the rule (Opt and Ops), = null and is null are allowed with =.
+// Values are converted to the Type of the rule. It panics on invalid expressions
+func FromExpr(rules []Rule, expr string) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildExpr(db, rules, expr)
+	}, false)
+}
+
+// FromExprE is like FromExpr but adds errors to db
+func FromExprE(rules []Rule, expr string) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildExpr(db, rules, expr)
+	}, true)
+}
+
+// buildExpr builds the condition of a filter expression
+func buildExpr(db *gorm.DB, rules []Rule, s string) (res result, err error) {
+	res.source = "expr"
+	tokens, err := exprTokens(s)
+	if err != nil {
+		return res, err
+	}
+	if len(tokens) == 0 {
+		return res, nil
+	}
+
+	p := exprParser{parser{db: db, rules: ruleMap(rules), tokens: tokens, end: len(s), lang: "expr", fold: true}}
+	expr, ok, err := p.parse(p.unary)
+	if err != nil {
+		return res, err
+	}
+	if ok {
+		res.conditions = append(res.conditions, condition{expr: expr})
+	}
+
+	return res, nil
+}
+
+// exprComparisons are the comparison symbols of filter expressions
+var exprComparisons = map[string]string{"=": Eq, "==": Eq, "!=": Neq, "<>": Neq, ">": GT, ">=": GTE, "<": LT, "<=": LTE}
+
+// exprTokens splits a filter expression into tokens
+func exprTokens(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{text: s[i : i+1], kind: c, offset: i})
+			i++
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			start := i
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, fmt.Errorf("%w: expr: unterminated string at position %d", ErrInvalidValue, start)
+				}
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				} else if s[i] == c {
+					i++
+					break
+				}
+				sb.WriteByte(s[i])
+			}
+			tokens = append(tokens, token{text: sb.String(), kind: 's', offset: start})
+		case strings.IndexByte("=!<>", c) >= 0:
+			start := i
+			for i++; i < len(s) && strings.IndexByte("=<>", s[i]) >= 0; i++ {
+			}
+			if _, ok := exprComparisons[s[start:i]]; !ok {
+				return nil, fmt.Errorf("%w: expr: unknown operator %q at position %d", ErrInvalidValue, s[start:i], start)
+			}
+			tokens = append(tokens, token{text: s[start:i], kind: 'o', offset: start})
+		case c == '-' || c >= '0' && c <= '9':
+			start := i
+			for i++; i < len(s) && (s[i] == '.' || s[i] >= '0' && s[i] <= '9'); i++ {
+			}
+			tokens = append(tokens, token{text: s[start:i], kind: 'n', offset: start})
+		case isIdentifier(s[i : i+1]):
+			start := i
+			for i++; i < len(s) && isIdentifier(s[i:i+1]); i++ {
+			}
+			tokens = append(tokens, token{text: s[start:i], kind: 'i', offset: start})
+		default:
+			return nil, fmt.Errorf("%w: expr: unexpected %q at position %d", ErrInvalidValue, c, i)
+		}
+	}
+	return tokens, nil
+}
+
+// exprParser is a recursive descent parser of filter expressions
+type exprParser struct {
+	parser
+}
+
+// unary parses a negated expression, an expression in parentheses or a comparison
+func (p *exprParser) unary() (clause.Expression, bool, error) {
+	if p.keyword("not") {
+		defer p.leave()
+		if err := p.enter(); err != nil {
+			return nil, false, err
+		}
+		expr, ok, err := p.unary()
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+		return not(expr), true, nil
+	}
+	if p.consume('(') {
+		return p.group(p.unary)
+	}
+	return p.comparison()
+}
+
+// comparison parses a field followed by an operator and its values
+func (p *exprParser) comparison() (clause.Expression, bool, error) {
+	name, err := p.expect('i', "a field")
+	if err != nil {
+		return nil, false, err
+	}
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == 'o' {
+		opt := exprComparisons[p.tokens[p.pos].text]
+		p.pos++
+		value, err := p.literal()
+		if err != nil {
+			return nil, false, err
+		}
+		return p.condition(name.text, opt, value)
+	}
+
+	switch {
+	case p.keyword("is"): // is null, is not null
+		opt := Eq
+		if p.keyword("not") {
+			opt = Neq
+		}
+		if !p.keyword("null") {
+			return nil, false, p.errorf("expected null")
+		}
+		return p.condition(name.text, opt, reflect.Value{})
+	case p.keyword("between"):
+		min, err := p.literal()
+		if err != nil {
+			return nil, false, err
+		}
+		if !p.keyword("and") {
+			return nil, false, p.errorf("expected and")
+		}
+		max, err := p.literal()
+		if err != nil {
+			return nil, false, err
+		}
+		if !min.IsValid() || !max.IsValid() {
+			return nil, false, fmt.Errorf("%w: between requires non-null values", ErrInvalidValue)
+		}
+		return p.condition(name.text, Between, reflect.ValueOf([]any{min.Interface(), max.Interface()}))
+	}
+
+	opt := In
+	if p.keyword("not") {
+		switch {
+		case p.keyword("in"):
+			opt = NotIn
+		case p.keyword("like"):
+			opt = NotLike
+		default:
+			return nil, false, p.errorf("expected in or like")
+		}
+	} else if !p.keyword("in") {
+		op, err := p.expect('i', "an operator")
+		if err != nil {
+			return nil, false, err
+		}
+		var ok bool
+		if opt, ok = operatorName(strings.ToLower(op.text)); !ok {
+			return nil, false, fmt.Errorf("%w: expr: unknown operator %q", ErrInvalidValue, op.text)
+		}
+	}
+	if opt != In && opt != NotIn {
+		value, err := p.literal()
+		if err != nil {
+			return nil, false, err
+		}
+		return p.condition(name.text, opt, value)
+	}
+
+	if !p.consume('(') {
+		return nil, false, p.errorf("expected (")
+	}
+	values, err := p.list(opt)
+	if err != nil {
+		return nil, false, err
+	}
+	return p.condition(name.text, opt, reflect.ValueOf(values))
+}
@@ -0,0 +1,52 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Select limits the selected columns to fields, e.g. from ?fields=id,name, which must be in allowed.
+// No fields select all columns, invalid fields are added to db as errors
+func Select(fields []string, allowed ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		columns, err := selectFields(reflect.ValueOf(fields), allowed)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		if len(columns) > 0 {
+			db.Select(columns)
+		}
+		return db
+	}
+}
+
+// selectFields returns the columns of a []string or comma-separated string value, which must be in allowed
+func selectFields(rfVal reflect.Value, allowed []string) ([]string, error) {
+	var fields []string
+	switch {
+	case rfVal.Kind() == reflect.String:
+		fields = strings.Split(rfVal.String(), ",")
+	case rfVal.Kind() == reflect.Slice && rfVal.Type().Elem().Kind() == reflect.String:
+		for i := 0; i < rfVal.Len(); i++ {
+			fields = append(fields, rfVal.Index(i).String())
+		}
+	default:
+		return nil, fmt.Errorf("%w: fields requires a string or []string value", ErrInvalidValue)
+	}
+
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field == "" || contains(columns, field) {
+			continue
+		}
+		if !isIdentifier(field) || !contains(allowed, field) {
+			return nil, fmt.Errorf("%w: selecting %q is not allowed", ErrInvalidColumn, field)
+		}
+		columns = append(columns, field)
+	}
+	return columns, nil
+}
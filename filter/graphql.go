@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GraphQLInput returns the GraphQL input object definition named name of the filter struct dest,
+// with a nullable field per filter field, e.g. for gqlgen schemas. Fields of joined structs and page, sort
+// and the other special fields are not included
+func GraphQLInput(name string, dest any) (string, error) {
+	rules, rt, err := graphQLRules(dest)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("input " + name + " {\n")
+	for _, fr := range rules {
+		sb.WriteString("  " + fr.Name + ": " + graphQLType(rt.FieldByIndex(fr.index).Type) + "\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// FromGraphQL applies the filter rules of the struct dest to the resolved values of its GraphQLInput object,
+// such as the map[string]any argument of a gqlgen resolver. It panics on invalid values
+func FromGraphQL(dest any, input map[string]any) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildGraphQL(db, dest, input)
+	}, false)
+}
+
+// FromGraphQLE is like FromGraphQL but adds errors to db
+func FromGraphQLE(dest any, input map[string]any) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildGraphQL(db, dest, input)
+	}, true)
+}
+
+// buildGraphQL builds the conditions of the GraphQL input values with the filter rules of dest
+func buildGraphQL(db *gorm.DB, dest any, input map[string]any) (res result, err error) {
+	fieldRules, _, err := graphQLRules(dest)
+	if err != nil {
+		return res, err
+	}
+	rules := make([]Rule, len(fieldRules))
+	for i, fr := range fieldRules {
+		rules[i] = fr.Rule
+	}
+	res, err = buildMap(db, rules, input)
+	res.source = "graphql"
+	return res, err
+}
+
+// graphQLRules returns the filter rules of the struct dest without the fields of joined structs and the special
+// fields, which are arguments of the query rather than filters
+func graphQLRules(dest any) ([]fieldRule, reflect.Type, error) {
+	rt := reflect.TypeOf(dest)
+	if rt == nil || indirectType(rt).Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("%w: %T is not a struct", ErrInvalidValue, dest)
+	}
+	rt = indirectType(rt)
+	all, err := std.fieldRules(rt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rules := make([]fieldRule, 0, len(all))
+	for _, fr := range all {
+		if fr.join == nil && fr.special == "" {
+			rules = append(rules, fr)
+		}
+	}
+	return rules, rt, nil
+}
+
+// graphQLType returns the GraphQL type of a filter field, Opt and sql.Null* like types use the type of their value
+func graphQLType(rt reflect.Type) string {
+	rt = indirectType(rt)
+	if rt == reflect.TypeOf(time.Time{}) {
+		return "String"
+	}
+	_, isOpt := reflect.Zero(rt).Interface().(optional)
+	isNull := rt.Implements(reflect.TypeOf((*driver.Valuer)(nil)).Elem()) && rt.Kind() == reflect.Struct && rt.NumField() > 0
+	if isNull {
+		if valid, ok := rt.FieldByName("Valid"); !ok || valid.Type.Kind() != reflect.Bool {
+			isNull = false
+		}
+	}
+	if isOpt || isNull {
+		return graphQLType(rt.Field(0).Type) // Opt.value, sql.NullString.String 等
+	}
+	if uuidArray(rt) {
+		return "ID"
+	}
+
+	switch rt.Kind() {
+	case reflect.Bool:
+		return "Boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Int"
+	case reflect.Float32, reflect.Float64:
+		return "Float"
+	case reflect.Slice, reflect.Array:
+		return "[" + graphQLType(rt.Elem()) + "]"
+	default:
+		return "String"
+	}
+}
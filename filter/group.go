@@ -0,0 +1,42 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GroupBy groups the query by cols, e.g. from ?group=status,city, which must be in allowed like the columns of Sort.
+// No columns leave the query ungrouped, invalid columns are added to db as errors
+func GroupBy(cols []string, allowed []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		columns, err := groupColumns(cols, allowed)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		if len(columns) > 0 {
+			db.Clauses(clause.GroupBy{Columns: columns})
+		}
+		return db
+	}
+}
+
+// groupColumns returns the group by columns of cols, which must be in allowed
+func groupColumns(cols []string, allowed []string) ([]clause.Column, error) {
+	columns := make([]clause.Column, 0, len(cols))
+	seen := make([]string, 0, len(cols))
+	for _, col := range cols {
+		if col = strings.TrimSpace(col); col == "" || contains(seen, col) {
+			continue
+		}
+		if !isIdentifier(col) || !contains(allowed, col) {
+			return nil, fmt.Errorf("%w: grouping by %q is not allowed", ErrInvalidColumn, col)
+		}
+		seen = append(seen, col)
+		columns = append(columns, clause.Column{Name: col})
+	}
+	return columns, nil
+}
@@ -0,0 +1,110 @@
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FromJSONAPI applies the JSON:API style filter parameters of the query string, e.g.
+// filter[name]=john&filter[age][gte]=30&filter[status]=a,b. Fields must be rule names, a plain filter uses the
+// operator of the rule and filter[field][op] the operators allowed by the rule (Opt and Ops).
+// Other parameters are ignored. It panics on invalid filters
+func FromJSONAPI(rules []Rule, q url.Values) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildJSONAPI(db, rules, q)
+	}, false)
+}
+
+// FromJSONAPIE is like FromJSONAPI but adds errors to db
+func FromJSONAPIE(rules []Rule, q url.Values) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildJSONAPI(db, rules, q)
+	}, true)
+}
+
+// buildJSONAPI builds the conditions from the rules and the filter parameters, in the order of the rules
+func buildJSONAPI(db *gorm.DB, rules []Rule, q url.Values) (res result, err error) {
+	res.source = "jsonapi"
+	filters := make(map[string]map[string][]string) // 字段名 -> 运算符 -> 值, 未指定运算符时为空字符串
+	for key, values := range q {
+		name, op, ok := jsonAPIKey(key)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if filters[name] == nil {
+			filters[name] = make(map[string][]string)
+		}
+		filters[name][op] = values
+	}
+
+	known := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		known[rule.Name] = true
+	}
+	for name := range filters {
+		if !known[name] {
+			return res, fmt.Errorf("%w: unknown field %q", ErrInvalidColumn, name)
+		}
+	}
+
+	for _, rule := range rules {
+		ops := filters[rule.Name]
+		delete(filters, rule.Name) // 同名规则只使用第一条
+		keys := make([]string, 0, len(ops))
+		for op := range ops {
+			keys = append(keys, op)
+		}
+		sort.Strings(keys)
+
+		for _, op := range keys {
+			r := rule
+			if op != "" {
+				opt, ok := operatorName(op)
+				if !ok {
+					return res, fmt.Errorf("%w: unknown operator %q", ErrInvalidValue, op)
+				}
+				if !allowsOperator(rule, opt) {
+					return res, fmt.Errorf("%w: operator %q is not allowed for %s", ErrInvalidValue, op, rule.Name)
+				}
+				r.Opt = opt
+			}
+
+			r, rfVal, err := queryValue(r, ops[op])
+			if err != nil {
+				return res, err
+			}
+			cond, ok, err := valueCondition(db, r, rfVal)
+			if err != nil {
+				return res, err
+			}
+			if ok {
+				res.conditions = append(res.conditions, cond)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// jsonAPIKey parses a filter[field] or filter[field][op] parameter name
+func jsonAPIKey(key string) (name, op string, ok bool) {
+	rest, found := strings.CutPrefix(key, "filter[")
+	if !found {
+		return "", "", false
+	}
+	name, rest, found = strings.Cut(rest, "]")
+	if !found || name == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return name, "", true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") || len(rest) < 3 {
+		return "", "", false
+	}
+	return name, rest[1 : len(rest)-1], true
+}
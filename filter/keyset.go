@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Keyset is a keyset (cursor) pagination ordered by columns in one direction, the last column must be unique,
+// e.g. created_at, id. Pages after the first are selected by a row comparison like (created_at, id) < (?, ?)
+// with the values of the last row of the previous page, which is much faster than offsets on large tables
+type Keyset struct {
+	Columns []string // 排序列, 最后一列须唯一
+	Desc    bool     // 是否降序, 默认升序
+	Size    int      // 每页数量, 为 0 时使用默认分页数量
+}
+
+// Paginate orders the query by the columns and limits it to the page after cursor, an empty cursor is the
+// first page. Invalid cursors and columns are added to db as errors
+func (k Keyset) Paginate(cursor string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(k.Columns) == 0 {
+			_ = db.AddError(fmt.Errorf("%w: keyset requires columns", ErrInvalidColumn))
+			return db
+		}
+		columns := make([]interface{}, len(k.Columns))
+		for i, name := range k.Columns {
+			if !isIdentifier(name) {
+				_ = db.AddError(fmt.Errorf("%w: %q", ErrInvalidColumn, name))
+				return db
+			}
+			column := clause.Column{Name: name}
+			columns[i] = column
+			db.Order(clause.OrderByColumn{Column: column, Desc: k.Desc})
+		}
+
+		if cursor != "" {
+			values, err := decodeCursor(cursor, len(k.Columns))
+			if err != nil {
+				_ = db.AddError(err)
+				return db
+			}
+			cmp := " > "
+			if k.Desc {
+				cmp = " < "
+			}
+			placeholders := "(" + strings.Repeat("?, ", len(k.Columns)-1) + "?)"
+			addWhere(db, clause.Expr{SQL: placeholders + cmp + placeholders, Vars: append(columns, values...)})
+		}
+
+		p := pagination{pageSize: k.Size}.normalize()
+		db.Limit(p.pageSize)
+		return db
+	}
+}
+
+// Cursor returns the cursor of the page after row, the last row of a page, which is a struct or a map
+// with the values of the columns
+func (k Keyset) Cursor(row any) (string, error) {
+	rv := reflect.ValueOf(row)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	values := make([]interface{}, len(k.Columns))
+	for i, column := range k.Columns {
+		if _, name, ok := strings.Cut(column, "."); ok { // 去除表名
+			column = name
+		}
+		v, ok := columnValue(rv, column)
+		if !ok {
+			return "", fmt.Errorf("%w: %T has no column %q", ErrInvalidValue, row, column)
+		}
+		if pv := reflect.ValueOf(v); pv.Kind() == reflect.Ptr && !pv.IsNil() {
+			v = pv.Elem().Interface()
+		}
+		if t, ok := v.(time.Time); ok { // 时间单独标记, 解码后仍绑定为时间
+			v = cursorTime{Time: t.Format(time.RFC3339Nano)}
+		}
+		values[i] = v
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// cursorTime is a time.Time value of a cursor
+type cursorTime struct {
+	Time string `json:"$time"`
+}
+
+// decodeCursor returns the n column values of a cursor
+func decodeCursor(cursor string, n int) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidValue)
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(b, &raws); err != nil || len(raws) != n {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidValue)
+	}
+
+	values := make([]interface{}, n)
+	for i, raw := range raws {
+		var t cursorTime
+		if json.Unmarshal(raw, &t) == nil && t.Time != "" {
+			if values[i], err = time.Parse(time.RFC3339Nano, t.Time); err != nil {
+				return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidValue)
+			}
+			continue
+		}
+		if err := decodeJSON(raw, &values[i]); err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidValue)
+		}
+	}
+	return values, nil
+}
+
+// columnValue returns the value of column in a map or a struct, whose fields are matched
+// by their gorm column or the column derived by the naming strategy
+func columnValue(rv reflect.Value, column string) (interface{}, bool) {
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		v := rv.MapIndex(reflect.ValueOf(column).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil, false
+		}
+		return v.Interface(), true
+	case reflect.Struct:
+		for _, field := range reflect.VisibleFields(rv.Type()) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			name := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")["COLUMN"]
+			if name == "" {
+				name = std.namer.ColumnName("", field.Name)
+			}
+			if name != column {
+				continue
+			}
+			v, err := rv.FieldByIndexErr(field.Index)
+			if err != nil {
+				return nil, false
+			}
+			return v.Interface(), true
+		}
+	}
+	return nil, false
+}
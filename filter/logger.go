@@ -0,0 +1,41 @@
+package filter
+
+// Logger receives the debug logs of the filter scopes with alternating keys and values,
+// *slog.Logger implements it, zap can be adapted with its SugaredLogger.Debugw
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+}
+
+var filterLogger Logger // 为空时不记录日志
+
+// SetLogger sets the logger of every filter scope, nil disables the logs
+func SetLogger(l Logger) {
+	filterLogger = l
+}
+
+// logResult logs the conditions and the skipped fields of res, or the error building it
+func logResult(res result, err error) {
+	l := filterLogger
+	if l == nil {
+		return
+	}
+	if err != nil {
+		l.Debug("filter: invalid filter", "error", err)
+		return
+	}
+	for _, s := range res.skipped {
+		l.Debug("filter: skipped", "field", s.Field, "name", s.Name, "reason", s.Reason)
+	}
+	rules := make([]string, 0, len(res.conditions))
+	for _, cond := range res.conditions {
+		if cond.name != "" { // 组合的条件, 如 RSQL 的表达式, 没有规则名
+			rules = append(rules, cond.name)
+		}
+	}
+	sql, vars, err := explain(res, nil)
+	if err != nil {
+		l.Debug("filter: invalid condition", "error", err)
+		return
+	}
+	l.Debug("filter: conditions", "sql", sql, "params", len(vars), "rules", rules)
+}
@@ -0,0 +1,176 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FromMongo applies a MongoDB style JSON filter such as {"age":{"$gte":30},"$or":[{"name":{"$like":"jo"}}]}.
+// Fields must be rule names, a plain value uses the operator of the rule and {"$op": value} the operators
+// allowed by the rule (Opt and Ops), $and and $or take arrays of filters. It panics on invalid filters
+func FromMongo(rules []Rule, data []byte) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildMongo(db, rules, data)
+	}, false)
+}
+
+// FromMongoE is like FromMongo but adds errors to db
+func FromMongoE(rules []Rule, data []byte) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildMongo(db, rules, data)
+	}, true)
+}
+
+// buildMongo builds the condition of a MongoDB style JSON filter
+func buildMongo(db *gorm.DB, rules []Rule, data []byte) (res result, err error) {
+	res.source = "mongo"
+	var doc map[string]json.RawMessage
+	if err := decodeJSON(data, &doc); err != nil {
+		return res, err
+	}
+
+	p := mongoParser{db: db, rules: ruleMap(rules)}
+	expr, ok, err := p.document(doc)
+	if err != nil {
+		return res, err
+	}
+	if ok {
+		res.conditions = append(res.conditions, condition{expr: expr})
+	}
+
+	return res, nil
+}
+
+// mongoParser parses MongoDB style filters against the rules keyed by name
+type mongoParser struct {
+	db    *gorm.DB
+	rules map[string]Rule
+}
+
+// document returns the conditions of a filter joined with AND, in the sorted order of the keys
+func (p mongoParser) document(doc map[string]json.RawMessage) (clause.Expression, bool, error) {
+	var exprs []clause.Expression
+	for _, key := range sortedKeys(doc) {
+		var expr clause.Expression
+		var ok bool
+		var err error
+		switch key {
+		case "$and", "$or":
+			expr, ok, err = p.logical(key, doc[key])
+		default:
+			expr, ok, err = p.field(key, doc[key])
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			exprs = append(exprs, expr)
+		}
+	}
+	return joinExprs(exprs, LogicAnd)
+}
+
+// logical returns the filters of an $and or $or array joined with the logic of the key
+func (p mongoParser) logical(key string, raw json.RawMessage) (clause.Expression, bool, error) {
+	var docs []map[string]json.RawMessage
+	if err := decodeJSON(raw, &docs); err != nil {
+		return nil, false, fmt.Errorf("%w: %s requires an array of filters", ErrInvalidValue, key)
+	}
+
+	exprs := make([]clause.Expression, 0, len(docs))
+	for _, doc := range docs {
+		expr, ok, err := p.document(doc)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			exprs = append(exprs, expr)
+		}
+	}
+	return joinExprs(exprs, strings.TrimPrefix(key, "$"))
+}
+
+// field returns the condition of a field, a value of {"$op": value} pairs applies each operator
+func (p mongoParser) field(name string, raw json.RawMessage) (clause.Expression, bool, error) {
+	rule, ok := p.rules[name]
+	if !ok {
+		return nil, false, fmt.Errorf("%w: unknown field %q", ErrInvalidColumn, name)
+	}
+
+	var ops map[string]json.RawMessage
+	if json.Unmarshal(raw, &ops) != nil || !operatorKeys(ops) { // 普通值使用规则的运算符
+		var v any
+		if err := decodeJSON(raw, &v); err != nil {
+			return nil, false, err
+		}
+		if v != nil { // 写出的零值都使用, 如 {"active": false}, null 仍然按规则处理
+			rule.UseZero = true
+		}
+		cond, ok, err := anyCondition(p.db, rule, v)
+		return cond.expr, ok, err
+	}
+
+	exprs := make([]clause.Expression, 0, len(ops))
+	for _, key := range sortedKeys(ops) {
+		opt, ok := operatorName(strings.TrimPrefix(key, "$"))
+		if !ok {
+			return nil, false, fmt.Errorf("%w: unknown operator %q", ErrInvalidValue, key)
+		}
+		if !allowsOperator(rule, opt) {
+			return nil, false, fmt.Errorf("%w: operator %q is not allowed for %s", ErrInvalidValue, key, name)
+		}
+		var v any
+		if err := decodeJSON(ops[key], &v); err != nil {
+			return nil, false, err
+		}
+		r := rule
+		r.Opt = opt
+		if v != nil {
+			r.UseZero = true
+		}
+		cond, ok, err := anyCondition(p.db, r, v)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			exprs = append(exprs, cond.expr)
+		}
+	}
+	return joinExprs(exprs, LogicAnd)
+}
+
+// operatorKeys reports whether the object is made up of $op keys
+func operatorKeys(obj map[string]json.RawMessage) bool {
+	for key := range obj {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return len(obj) > 0
+}
+
+// decodeJSON decodes data into v, numbers are decoded as json.Number to keep their precision
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in sorted order, so the same filter always generates the same SQL
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,166 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FromOData applies a subset of an OData $filter expression such as
+// age ge 30 and (contains(name,'jo') or status in ('a','b')). It supports the comparisons eq, ne, gt, ge, lt, le
+// and in, the functions contains, startswith and endswith, and, or and parentheses. Fields must be rule names and
+// the operators must be allowed by the rule (Opt and Ops), eq null and ne null are allowed with eq and ne.
+// Values are converted to the Type of the rule. It panics on invalid filters
+func FromOData(rules []Rule, filter string) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildOData(db, rules, filter)
+	}, false)
+}
+
+// FromODataE is like FromOData but adds errors to db
+func FromODataE(rules []Rule, filter string) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildOData(db, rules, filter)
+	}, true)
+}
+
+// buildOData builds the condition of an OData $filter expression
+func buildOData(db *gorm.DB, rules []Rule, filter string) (res result, err error) {
+	res.source = "odata"
+	tokens, err := odataTokens(filter)
+	if err != nil {
+		return res, err
+	}
+	if len(tokens) == 0 {
+		return res, nil
+	}
+
+	p := odataParser{parser{db: db, rules: ruleMap(rules), tokens: tokens, end: len(filter), lang: "odata"}}
+	expr, ok, err := p.parse(p.primary)
+	if err != nil {
+		return res, err
+	}
+	if ok {
+		res.conditions = append(res.conditions, condition{expr: expr})
+	}
+
+	return res, nil
+}
+
+// odataComparisons are the OData comparison operators
+var odataComparisons = map[string]string{"eq": Eq, "ne": Neq, "gt": GT, "ge": GTE, "lt": LT, "le": LTE, "in": In}
+
+// odataFunctions are the OData string functions
+var odataFunctions = map[string]string{"contains": Like, "startswith": StartsWith, "endswith": EndsWith}
+
+// odataTokens splits an OData expression into tokens
+func odataTokens(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{text: s[i : i+1], kind: c, offset: i})
+			i++
+		case c == '\'': // 字符串中的 '' 表示单引号
+			var sb strings.Builder
+			start := i
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, fmt.Errorf("%w: odata: unterminated string at position %d", ErrInvalidValue, start)
+				}
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						i++
+					} else {
+						i++
+						break
+					}
+				}
+				sb.WriteByte(s[i])
+			}
+			tokens = append(tokens, token{text: sb.String(), kind: 's', offset: start})
+		case c == '-' || c >= '0' && c <= '9':
+			start := i
+			for i++; i < len(s) && (s[i] == '.' || s[i] >= '0' && s[i] <= '9'); i++ {
+			}
+			tokens = append(tokens, token{text: s[start:i], kind: 'n', offset: start})
+		case isIdentifier(s[i : i+1]):
+			start := i
+			for i++; i < len(s) && isIdentifier(s[i:i+1]); i++ {
+			}
+			tokens = append(tokens, token{text: s[start:i], kind: 'i', offset: start})
+		default:
+			return nil, fmt.Errorf("%w: odata: unexpected %q at position %d", ErrInvalidValue, c, i)
+		}
+	}
+	return tokens, nil
+}
+
+// odataParser is a recursive descent parser of OData expressions
+type odataParser struct {
+	parser
+}
+
+// primary parses a comparison, a function call or an expression in parentheses
+func (p *odataParser) primary() (clause.Expression, bool, error) {
+	if p.consume('(') {
+		return p.group(p.primary)
+	}
+
+	name, err := p.expect('i', "a field or function")
+	if err != nil {
+		return nil, false, err
+	}
+	if p.consume('(') { // contains(name,'jo')
+		opt, ok := odataFunctions[name.text]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: odata: unknown function %q", ErrInvalidValue, name.text)
+		}
+		field, err := p.expect('i', "a field")
+		if err != nil {
+			return nil, false, err
+		}
+		if !p.consume(',') {
+			return nil, false, p.errorf("expected ,")
+		}
+		value, err := p.expect('s', "a string")
+		if err != nil {
+			return nil, false, err
+		}
+		if !p.consume(')') {
+			return nil, false, p.errorf("expected )")
+		}
+		return p.condition(field.text, opt, reflect.ValueOf(value.text))
+	}
+
+	op, err := p.expect('i', "an operator")
+	if err != nil {
+		return nil, false, err
+	}
+	opt, ok := odataComparisons[op.text]
+	if !ok {
+		return nil, false, fmt.Errorf("%w: odata: unknown operator %q", ErrInvalidValue, op.text)
+	}
+	if opt != In {
+		value, err := p.literal()
+		if err != nil {
+			return nil, false, err
+		}
+		return p.condition(name.text, opt, value)
+	}
+
+	if !p.consume('(') {
+		return nil, false, p.errorf("expected (")
+	}
+	values, err := p.list(opt)
+	if err != nil {
+		return nil, false, err
+	}
+	return p.condition(name.text, opt, reflect.ValueOf(values))
+}
@@ -0,0 +1,67 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Opt is an optional filter value which tells an unset field from its zero value,
+// the condition is only applied when the value is set, even if it is the zero value
+type Opt[T any] struct {
+	value T
+	set   bool
+}
+
+// Set sets the value
+func (o *Opt[T]) Set(v T) {
+	o.value = v
+	o.set = true
+}
+
+// Unset clears the value
+func (o *Opt[T]) Unset() {
+	var zero T
+	o.value = zero
+	o.set = false
+}
+
+// IsSet reports whether the value is set
+func (o Opt[T]) IsSet() bool {
+	return o.set
+}
+
+// Get returns the value, the zero value if unset
+func (o Opt[T]) Get() T {
+	return o.value
+}
+
+// UnmarshalJSON sets the value, null leaves it unset
+func (o *Opt[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Unset()
+		return nil
+	}
+	if err := json.Unmarshal(data, &o.value); err != nil {
+		return err
+	}
+	o.set = true
+	return nil
+}
+
+// MarshalJSON returns the value, null if unset
+func (o Opt[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+func (o Opt[T]) optionalValue() (reflect.Value, bool) {
+	return reflect.ValueOf(&o.value).Elem(), o.set
+}
+
+// optional is implemented by Opt
+type optional interface {
+	optionalValue() (reflect.Value, bool)
+}
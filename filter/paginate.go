@@ -0,0 +1,133 @@
+package filter
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	defaultPageSize = 20  // 未指定每页数量时使用
+	maxPageSize     = 100 // 每页数量上限
+)
+
+// SetPageSize sets the default page size, 20, and the maximum page size, 100, a max of 0 disables the limit
+func SetPageSize(defaultSize, maxSize int) {
+	defaultPageSize = defaultSize
+	maxPageSize = maxSize
+}
+
+// Paginate limits the query to the page, starting from 1, of pageSize rows. Pages below 1 are the first page,
+// a pageSize below 1 uses the default page size and larger sizes are capped by the maximum page size
+func Paginate(page, pageSize int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		p := pagination{page: page, pageSize: pageSize}
+		p.apply(db)
+		return db
+	}
+}
+
+// pagination is the page of a query
+type pagination struct {
+	page     int
+	pageSize int
+}
+
+// normalize returns the page with the defaults and limits applied
+func (p pagination) normalize() pagination {
+	if p.page < 1 {
+		p.page = 1
+	}
+	if p.pageSize < 1 {
+		p.pageSize = defaultPageSize
+	}
+	if maxPageSize > 0 && p.pageSize > maxPageSize {
+		p.pageSize = maxPageSize
+	}
+	return p
+}
+
+// apply adds the offset and limit of the page to db
+func (p pagination) apply(db *gorm.DB) {
+	p = p.normalize()
+	db.Offset((p.page - 1) * p.pageSize).Limit(p.pageSize)
+}
+
+// Page is a page of query results with the total number of rows matching the filter
+type Page struct {
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Items    any   `json:"items"` // FindPage 的 dest
+}
+
+// FindPage counts the rows matching the filter struct and finds the page of them given by its page and
+// page_size fields, or the first page of the default size, into dest, a pointer to a slice of models.
+// page is filled with the total, the page and dest as its items
+func FindPage(db *gorm.DB, dest any, filter any, page *Page) error {
+	res, err := std.buildFilter(db, filter)
+	if err != nil {
+		return err
+	}
+	var p pagination
+	if res.page != nil {
+		p = *res.page
+	}
+	p = p.normalize()
+
+	find := db.Session(&gorm.Session{}).Scopes(scope(func(db *gorm.DB) (result, error) {
+		res, err := std.buildFilter(db, filter)
+		res.page = &p
+		return res, err
+	}, true))
+
+	*page = Page{Page: p.page, PageSize: p.pageSize, Items: dest}
+	if err := countRows(db.Session(&gorm.Session{}).Model(dest), filter, &page.Total); err != nil {
+		return err
+	}
+	if page.Total <= int64((p.page-1)*p.pageSize) { // 没有更多数据时不再查询
+		return nil
+	}
+	return find.Find(dest).Error
+}
+
+// Count counts the rows of db matching the filter of dest, ignoring its page, sort and fields and the limit,
+// offset and order already added to db, so the count and the list are built from identical conditions,
+// e.g. total, err := filter.Count(db.Model(&User{}), f) before db.Scopes(filter.FilterE(f)).Find(&users).
+// It runs the count itself rather than being a scope, whose count would run while scopes are applied and
+// depend on their order. Joins on to-many relations with distinct count the distinct primary keys
+func Count(db *gorm.DB, dest any) (int64, error) {
+	var total int64
+	err := countRows(db, dest, &total)
+	return total, err
+}
+
+// countRows counts the rows of db matching the filter of dest into total, the count query of Count and FindPage
+func countRows(db *gorm.DB, dest any, total *int64) error {
+	return db.Session(&gorm.Session{}).Scopes(countScope(dest), unpaged, distinctCount).Count(total).Error
+}
+
+// distinctCount counts the distinct primary keys of queries made distinct by to-many joins, gorm ignores
+// Distinct without selected columns and would count the joined rows. It runs after the scopes added before it
+func distinctCount(db *gorm.DB) *gorm.DB {
+	if db.Statement.Distinct && len(db.Statement.Selects) == 0 {
+		pk := clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}
+		db.Statement.AddClause(clause.Select{Expression: clause.Expr{SQL: "count(distinct ?)", Vars: []interface{}{pk}}})
+	}
+	return db
+}
+
+// unpaged removes the limit, offset and order of the query, it runs after the scopes added before it
+func unpaged(db *gorm.DB) *gorm.DB {
+	delete(db.Statement.Clauses, "LIMIT")
+	delete(db.Statement.Clauses, "ORDER BY")
+	return db
+}
+
+// countScope returns the filter scope of dest for counting, without its page, sort and fields
+func countScope(dest any) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		res, err := std.buildFilter(db, dest)
+		res.page, res.fields, res.orders = nil, nil, nil // 计数不分页, 不排序, 不限制查询列
+		return res, err
+	}, true)
+}
@@ -0,0 +1,240 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// token is a token of an OData or filter expression
+type token struct {
+	text   string
+	kind   byte // i: 名称, s: 字符串, n: 数字, o: 比较符, 其他为标点 ( ) ,
+	offset int
+}
+
+// parser is the recursive descent core shared by the OData and filter expression parsers,
+// the languages parse their own operands
+type parser struct {
+	db     *gorm.DB
+	rules  map[string]Rule
+	tokens []token
+	pos    int
+	end    int    // 表达式长度, 用于报告结尾处的错误
+	lang   string // 错误信息中的语言名
+	fold   bool   // 关键字是否忽略大小写
+	depth  int    // 当前的括号和 not 嵌套层数
+}
+
+// maxNesting is the maximum nesting of parentheses and negations in filter languages,
+// deeper input would exhaust the stack of the recursive descent parsers
+const maxNesting = 100
+
+// enter enters a nested expression, leave must be called when it is parsed
+func (p *parser) enter() error {
+	if p.depth++; p.depth > maxNesting {
+		return p.errorf("nesting deeper than %d", maxNesting)
+	}
+	return nil
+}
+
+// leave leaves a nested expression
+func (p *parser) leave() {
+	p.depth--
+}
+
+// parse parses the whole expression with the operand parser, ok is false if it has no condition
+func (p *parser) parse(operand func() (clause.Expression, bool, error)) (clause.Expression, bool, error) {
+	expr, ok, err := p.or(operand)
+	if err != nil {
+		return nil, false, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, false, p.errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return expr, ok, nil
+}
+
+// or parses expressions separated by or
+func (p *parser) or(operand func() (clause.Expression, bool, error)) (clause.Expression, bool, error) {
+	var exprs []clause.Expression
+	for {
+		expr, ok, err := p.and(operand)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			exprs = append(exprs, expr)
+		}
+		if !p.keyword("or") {
+			return joinExprs(exprs, LogicOr)
+		}
+	}
+}
+
+// and parses operands separated by and
+func (p *parser) and(operand func() (clause.Expression, bool, error)) (clause.Expression, bool, error) {
+	var exprs []clause.Expression
+	for {
+		expr, ok, err := operand()
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			exprs = append(exprs, expr)
+		}
+		if !p.keyword("and") {
+			return joinExprs(exprs, LogicAnd)
+		}
+	}
+}
+
+// group parses an expression in parentheses after the (
+func (p *parser) group(operand func() (clause.Expression, bool, error)) (clause.Expression, bool, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return nil, false, err
+	}
+	expr, ok, err := p.or(operand)
+	if err != nil {
+		return nil, false, err
+	}
+	if !p.consume(')') {
+		return nil, false, p.errorf("expected )")
+	}
+	return expr, ok, nil
+}
+
+// literal parses a string, number, true, false or null, which is an invalid value
+func (p *parser) literal() (reflect.Value, error) {
+	switch {
+	case p.keyword("true"):
+		return reflect.ValueOf(true), nil
+	case p.keyword("false"):
+		return reflect.ValueOf(false), nil
+	case p.keyword("null"):
+		return reflect.Value{}, nil
+	}
+	if p.pos < len(p.tokens) && (p.tokens[p.pos].kind == 's' || p.tokens[p.pos].kind == 'n') {
+		p.pos++
+		return reflect.ValueOf(p.tokens[p.pos-1].text), nil
+	}
+	return reflect.Value{}, p.errorf("expected a value")
+}
+
+// list parses literals separated by , up to the closing ) after the (
+func (p *parser) list(opt string) ([]any, error) {
+	var values []any
+	for {
+		value, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		if !value.IsValid() {
+			return nil, fmt.Errorf("%w: %s requires non-null values", ErrInvalidValue, opt)
+		}
+		values = append(values, value.Interface())
+		if p.consume(')') {
+			return values, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+}
+
+// condition returns the condition of the field with the operator, an invalid value is null
+func (p *parser) condition(name, opt string, value reflect.Value) (clause.Expression, bool, error) {
+	rule, ok := p.rules[name]
+	if !ok {
+		return nil, false, fmt.Errorf("%w: unknown field %q", ErrInvalidColumn, name)
+	}
+	if !allowsOperator(rule, opt) {
+		return nil, false, fmt.Errorf("%w: operator %q is not allowed for %s", ErrInvalidValue, opt, name)
+	}
+	rule.Opt = opt
+	rule.UseZero = true // 表达式中写出的值都使用, 如 active eq false
+
+	if value.IsValid() {
+		var err error
+		if value, err = typedLiteral(rule, value); err != nil {
+			return nil, false, err
+		}
+	} else { // eq null, is null
+		if opt != Eq && opt != Neq {
+			return nil, false, fmt.Errorf("%w: %s rule requires a non-null value", ErrInvalidValue, opt)
+		}
+		var v any
+		value = reflect.ValueOf(&v).Elem()
+	}
+	cond, ok, err := valueCondition(p.db, rule, value)
+	return cond.expr, ok, err
+}
+
+// expect returns the next token, which must be of the kind
+func (p *parser) expect(kind byte, what string) (token, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != kind {
+		return token{}, p.errorf("expected %s", what)
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+// keyword skips the next token if it is the name word, ignoring case if the language does
+func (p *parser) keyword(word string) bool {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != 'i' {
+		return false
+	}
+	if text := p.tokens[p.pos].text; text == word || p.fold && strings.EqualFold(text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// consume skips the next token if it is the punctuation c
+func (p *parser) consume(c byte) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// errorf returns a syntax error at the current token
+func (p *parser) errorf(format string, args ...any) error {
+	offset := p.end
+	if p.pos < len(p.tokens) {
+		offset = p.tokens[p.pos].offset
+	}
+	return fmt.Errorf("%w: %s: %s at position %d", ErrInvalidValue, p.lang, fmt.Sprintf(format, args...), offset)
+}
+
+// typedLiteral converts the string and number literals of value, a literal or a list of them, to the Type of
+// rule like query parameters. Without a Type they stay strings converted to the column type by the database
+func typedLiteral(rule Rule, value reflect.Value) (reflect.Value, error) {
+	values, isList := value.Interface().([]any)
+	if !isList {
+		values = []any{value.Interface()}
+	}
+	typed := make([]any, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			typed[i] = v
+			continue
+		}
+		t, err := typedValues(rule, []string{s})
+		if err != nil {
+			return value, err
+		}
+		typed[i] = t.Index(0).Interface()
+	}
+	if !isList {
+		return reflect.ValueOf(typed[0]), nil
+	}
+	return reflect.ValueOf(typed), nil
+}
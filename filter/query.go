@@ -0,0 +1,185 @@
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FromQuery applies the rules to the query string parameters named by rule name, e.g. ?name=john&ids=1,2.
+// Parameters without a rule are ignored. The operator of a rule with Ops can be given in the value,
+// e.g. ?age=gte:30, if it is one of the allowed operators. It panics on invalid values
+func FromQuery(rules []Rule, q url.Values) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildQuery(db, rules, q)
+	}, false)
+}
+
+// FromQueryE is like FromQuery but adds errors to db
+func FromQueryE(rules []Rule, q url.Values) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildQuery(db, rules, q)
+	}, true)
+}
+
+// buildQuery builds the conditions from the rules and the query string parameters
+func buildQuery(db *gorm.DB, rules []Rule, q url.Values) (res result, err error) {
+	res.source = "query"
+	for _, rule := range rules {
+		if !whenHolds(rule, func(name string) (reflect.Value, bool) {
+			return reflect.ValueOf(q.Get(name)), q.Has(name)
+		}) {
+			continue
+		}
+		values, ok := q[rule.Name]
+		if !ok || len(values) == 0 {
+			cond, ok, err := defaultCondition(db, rule)
+			if err != nil {
+				return res, err
+			}
+			if ok {
+				res.conditions = append(res.conditions, cond)
+			}
+			continue
+		}
+
+		if opt, value, ok := queryOperator(values[0]); ok && len(rule.Ops) > 0 {
+			if !allowsOperator(rule, opt) {
+				return res, fmt.Errorf("%w: operator %q is not allowed for %s", ErrInvalidValue, opt, rule.Name)
+			}
+			rule.Opt = opt
+			values = append([]string{value}, values[1:]...)
+		}
+
+		rule, rfVal, err := queryValue(rule, values)
+		if err != nil {
+			return res, err
+		}
+		cond, ok, err := valueCondition(db, rule, rfVal)
+		if err != nil {
+			return res, err
+		}
+		if ok {
+			res.conditions = append(res.conditions, cond)
+		}
+	}
+
+	return res, nil
+}
+
+// queryValue converts the parameter values to the value the rule operator expects:
+// a slice for multi-value operators, from repeated parameters or comma-separated values,
+// a bool for is_null and not_null, and the first value otherwise. Values are converted to the Type of
+// the rule, strings without a Type are converted to the column type by the database. The returned rule
+// uses the zero values converted from parameters, such as ?active=false
+func queryValue(rule Rule, values []string) (Rule, reflect.Value, error) {
+	if multiValue(rule.Opt) {
+		if len(values) == 1 { // ids=1,2 等同于 ids=1&ids=2
+			values = strings.Split(values[0], ",")
+		}
+		v, err := typedValues(rule, values)
+		return rule, v, err
+	}
+	switch rule.Opt {
+	case Exists:
+		if len(values) > 1 {
+			v, err := typedValues(rule, values)
+			return rule, v, err
+		}
+	case IsNull, NotNull:
+		b, _ := strconv.ParseBool(values[0]) // 无法解析时视为 false, 不添加条件
+		return rule, reflect.ValueOf(b), nil
+	}
+	v, err := typedValues(rule, values[:1])
+	if err != nil {
+		return rule, v, err
+	}
+	value := v.Index(0).Interface()
+	if _, isStr := value.(string); !isStr { // 转换后的零值, 如 false 和 0, 是写出的值
+		rule.UseZero = true
+	}
+	return rule, reflect.ValueOf(value), nil
+}
+
+// typedValues converts the parameter values to the Type of rule, they stay strings without a Type, with a Map,
+// for operators matching strings and for date ranges, which parse their own bounds. Blank values stay empty
+// strings to be skipped like missing values
+func typedValues(rule Rule, values []string) (reflect.Value, error) {
+	switch {
+	case rule.Type == "" || rule.Type == TypeString || rule.Map != nil:
+		return reflect.ValueOf(values), nil
+	}
+	switch rule.Opt {
+	case Like, NotLike, StartsWith, EndsWith, ILike, Rlike, Regexp, DateRange, DatetimeRange:
+		return reflect.ValueOf(values), nil
+	}
+	typed := make([]any, len(values))
+	for i, s := range values {
+		if s = strings.TrimSpace(s); s == "" {
+			typed[i] = s
+			continue
+		}
+		v, err := typedValue(rule, s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s: %v", ErrInvalidValue, rule.Name, err)
+		}
+		typed[i] = v
+	}
+	return reflect.ValueOf(typed), nil
+}
+
+// multiValue reports whether the operator takes a list of values
+func multiValue(opt string) bool {
+	switch opt {
+	case In, NotIn, ArrayContains, Between, DateRange, DatetimeRange:
+		return true
+	}
+	return false
+}
+
+// operatorAliases are the operator names accepted in query parameters and filter expressions besides the operators themselves
+var operatorAliases = map[string]string{"eq": Eq, "ne": Neq, "neq": Neq, "gt": GT, "gte": GTE, "lt": LT, "lte": LTE, "nin": NotIn}
+
+// operatorName returns the operator of an operator name or alias, ok is false for unknown operators
+func operatorName(name string) (opt string, ok bool) {
+	if opt, ok := operatorAliases[name]; ok {
+		return opt, true
+	}
+	if _, ok := lookupOperator(name); builtinOperators[name] || ok {
+		return name, true
+	}
+	return "", false
+}
+
+// queryOperator splits an operator prefix such as gte: off the parameter value,
+// ok is false if the value has no prefix or the prefix is not a known operator
+func queryOperator(value string) (opt, rest string, ok bool) {
+	name, rest, found := strings.Cut(value, ":")
+	if !found {
+		return "", value, false
+	}
+	if opt, ok = operatorName(name); !ok {
+		return "", value, false
+	}
+	return opt, rest, true
+}
+
+// allowsOperator reports whether opt is the operator of rule or one of the operators allowed by rule.Ops
+func allowsOperator(rule Rule, opt string) bool {
+	if rule.Opt == opt || rule.Opt == "" && opt == Eq {
+		return true
+	}
+	for _, allowed := range rule.Ops {
+		if o, ok := operatorAliases[allowed]; ok {
+			allowed = o
+		}
+		if allowed == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// relCondition returns the condition of a rel rule, matching the rows whose related rows satisfy the rule,
+// e.g. id IN (SELECT user_id FROM orders WHERE status = ?) for rel:Orders of a user model with many orders.
+// The relationship and its keys come from the gorm schema of the model of db
+func relCondition(db *gorm.DB, rule Rule, rfVal reflect.Value) (cond condition, ok bool, err error) {
+	rel, err := relationship(db, rule.Rel)
+	if err != nil {
+		return cond, false, err
+	}
+
+	var own, related *schema.Field // 主表与关联表的关联字段
+	var values []clause.Expression // 多态关联的类型等固定值条件
+	for _, ref := range rel.References {
+		switch {
+		case ref.PrimaryKey == nil: // 多态关联的类型
+			values = append(values, clause.Eq{Column: clause.Column{Table: rel.FieldSchema.Table, Name: ref.ForeignKey.DBName}, Value: ref.PrimaryValue})
+		case own != nil:
+			return cond, false, fmt.Errorf("%w: rel %q: composite keys are not supported", ErrInvalidTag, rule.Rel)
+		case ref.OwnPrimaryKey: // has one / has many
+			own, related = ref.PrimaryKey, ref.ForeignKey
+		default: // belongs to
+			own, related = ref.ForeignKey, ref.PrimaryKey
+		}
+	}
+	if own == nil {
+		return cond, false, fmt.Errorf("%w: rel %q has no keys", ErrInvalidTag, rule.Rel)
+	}
+
+	inner := rule
+	inner.Rel, inner.Table, inner.Alias = "", "", rel.FieldSchema.Table // 子查询中的列使用关联表限定
+	innerCond, ok, err := parseRule(db, inner, rfVal)
+	if err != nil || !ok {
+		return cond, ok, err
+	}
+
+	cond = innerCond
+	cond.expr = clause.Expr{
+		SQL: "? IN (SELECT ? FROM ? WHERE ?)",
+		Vars: []interface{}{
+			clause.Column{Table: clause.CurrentTable, Name: own.DBName},
+			clause.Column{Table: rel.FieldSchema.Table, Name: related.DBName},
+			clause.Table{Name: rel.FieldSchema.Table},
+			clause.And(append(values, innerCond.expr)...),
+		},
+	}
+	return cond, true, nil
+}
+
+// relationship returns the has one, has many or belongs to relationship name of the model of db
+func relationship(db *gorm.DB, name string) (*schema.Relationship, error) {
+	if db == nil {
+		return nil, fmt.Errorf("%w: rel %q requires a database session", ErrInvalidTag, name)
+	}
+	s := db.Statement.Schema
+	if s == nil {
+		model := db.Statement.Model
+		if model == nil {
+			model = db.Statement.Dest
+		}
+		if model == nil {
+			return nil, fmt.Errorf("%w: rel %q requires a model", ErrInvalidTag, name)
+		}
+		stmt := &gorm.Statement{DB: db} // 不修改当前查询的 Statement
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("%w: rel %q: %v", ErrInvalidTag, name, err)
+		}
+		s = stmt.Schema
+	}
+
+	rel, ok := s.Relationships.Relations[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no relationship %q", ErrInvalidTag, s.Name, name)
+	}
+	switch rel.Type {
+	case schema.HasOne, schema.HasMany, schema.BelongsTo:
+		return rel, nil
+	default:
+		return nil, fmt.Errorf("%w: rel %q: %s relationships are not supported", ErrInvalidTag, name, rel.Type)
+	}
+}
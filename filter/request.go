@@ -0,0 +1,224 @@
+package filter
+
+import (
+	"database/sql"
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FromRequest binds the query parameters of r into the filter fields of the struct dest by their json names,
+// checks its filter tags and values, and returns the Filter scope of dest. dest must be a pointer.
+// Parameters are converted to the field types: numbers, bools, time.Time (layout tag, RFC 3339 or date,
+// in the tz tag or SetDateLocation time zone),
+// Opt, sql.Null* and encoding.TextUnmarshaler types, and slices or arrays such as date pairs from repeated
+// or comma-separated values, e.g. ?day=2024-01-01,2024-01-31. Empty parameters are ignored
+func FromRequest(r *http.Request, dest any) (func(*gorm.DB) *gorm.DB, error) {
+	if err := bindValues(dest, r.URL.Query(), std.fieldName); err != nil {
+		return nil, err
+	}
+	return bindScope(dest)
+}
+
+// bindValues sets the filter fields of the struct dest points to from the values named by name
+func bindValues(dest any, values url.Values, name func(reflect.StructField) string) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a pointer to a struct", ErrInvalidValue, dest)
+	}
+	_, err := bindStruct(rv.Elem(), values, name)
+	return err
+}
+
+// bindStruct sets the filter fields of rv, set reports whether any field is set
+func bindStruct(rv reflect.Value, values url.Values, name func(reflect.StructField) string) (set bool, err error) {
+	for _, field := range reflect.VisibleFields(rv.Type()) {
+		filterTagStr := strings.Trim(field.Tag.Get(std.tagKey), " ;,")
+		if filterTagStr == "" || filterTagStr == "-" || !field.IsExported() {
+			continue
+		}
+		rule, err := parseTag(filterTagStr, false)
+		if err != nil {
+			return set, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		fv, err := rv.FieldByIndexErr(field.Index)
+		if err != nil { // 嵌入的结构体指针为 nil
+			continue
+		}
+
+		if rule.Join != "" { // 关联结构体的字段使用同一组参数
+			nested := reflect.New(indirectType(field.Type)).Elem()
+			if fv.Kind() != reflect.Ptr {
+				nested = fv
+			} else if !fv.IsNil() {
+				nested = fv.Elem()
+			}
+			ok, err := bindStruct(nested, values, name)
+			if err != nil {
+				return set, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			if ok && fv.Kind() == reflect.Ptr && fv.IsNil() {
+				fv.Set(nested.Addr())
+			}
+			set = set || ok
+			continue
+		}
+
+		vals := values[name(field)]
+		if len(vals) == 0 || len(vals) == 1 && vals[0] == "" {
+			continue
+		}
+		if err := setValue(fv, vals, rule); err != nil {
+			return set, fmt.Errorf("%w: field %s: %v", ErrInvalidValue, field.Name, err)
+		}
+		set = true
+	}
+
+	return set, nil
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// setValue converts the parameter values of the rule to the type of fv and sets it. Blank values leave fv unset,
+// blank elements are skipped except in arrays and the slices of range rules, whose bounds they leave empty
+func setValue(fv reflect.Value, vals []string, rule Rule) error {
+	if len(vals) == 1 && strings.TrimSpace(vals[0]) == "" { // 指针保持为 nil
+		return nil
+	}
+	rt := fv.Type()
+	switch {
+	case rt.Kind() == reflect.Ptr:
+		elem := reflect.New(rt.Elem())
+		if err := setValue(elem.Elem(), vals, rule); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	case rt.Implements(reflect.TypeOf((*optional)(nil)).Elem()): // Opt[T]
+		v := reflect.New(rt.Field(0).Type).Elem()
+		if err := setValue(v, vals, rule); err != nil {
+			return err
+		}
+		fv.Addr().MethodByName("Set").Call([]reflect.Value{v})
+		return nil
+	case rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array:
+		if rt.Elem().Kind() == reflect.Uint8 && rt.Kind() == reflect.Slice { // []byte
+			break
+		}
+		if len(vals) == 1 { // day=2024-01-01,2024-01-31 等同于 day=2024-01-01&day=2024-01-31
+			vals = strings.Split(vals[0], ",")
+		}
+		if rt.Kind() == reflect.Slice && rule.Opt != Between && rule.Opt != DateRange && rule.Opt != DatetimeRange {
+			if vals = nonBlank(vals); len(vals) == 0 { // ids=1,,2 只使用 1 和 2
+				return nil
+			}
+		}
+		if rt.Kind() == reflect.Array && len(vals) > rt.Len() {
+			return fmt.Errorf("at most %d values", rt.Len())
+		}
+		if rt.Kind() == reflect.Slice {
+			fv.Set(reflect.MakeSlice(rt, len(vals), len(vals)))
+		}
+		for i, s := range vals {
+			if err := setValue(fv.Index(i), []string{s}, rule); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s := strings.TrimSpace(vals[0])
+	if s == "" {
+		return nil
+	}
+	switch {
+	case rt == timeType:
+		t, err := parseTime(s, rule.Layout, rule.TZ)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case reflect.PointerTo(rt).Implements(textUnmarshalerType):
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	case reflect.PointerTo(rt).Implements(scannerType): // sql.NullInt64 等
+		return fv.Addr().Interface().(sql.Scanner).Scan(s)
+	}
+
+	switch rt.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rt.Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, rt.Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rt.Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice: // []byte
+		fv.SetBytes([]byte(s))
+	case reflect.Interface:
+		fv.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("unsupported type %s", rt)
+	}
+	return nil
+}
+
+// nonBlank returns the values that are not blank
+func nonBlank(vals []string) []string {
+	kept := vals[:0:0]
+	for _, s := range vals {
+		if strings.TrimSpace(s) != "" {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// parseTime parses s with layout, or as RFC 3339, date time or date without layout,
+// in the time zone tz like date_range rules
+func parseTime(s, layout, tz string) (time.Time, error) {
+	loc, err := timeLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if layout != "" {
+		return time.ParseInLocation(layout, s, loc)
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,82 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMissingScope = errors.New("missing required filter scope") // 必需的条件在查询的 context 中没有值
+	ErrUnfiltered   = errors.New("too few filter conditions")     // 过滤条件少于 RequireAtLeast 的数量
+)
+
+// RequiredFunc returns the rule and value of a condition every filter must include, read from the context
+// of the query, e.g. the tenant of the request. ok is false when the context has no value, which fails the query
+type RequiredFunc func(ctx context.Context) (rule Rule, value any, ok bool)
+
+var (
+	requiredMu    sync.RWMutex
+	requiredFuncs []RequiredFunc
+
+	minConditions int // 过滤条件的最少数量, 与 requiredFuncs 一样由 requiredMu 保护
+)
+
+// RequireScope registers a condition prepended to the WHERE of every filter scope, even those without
+// conditions, such as tenant_id = ? with the tenant from db.WithContext(ctx). A missing value adds
+// ErrMissingScope to db instead of running the query unscoped
+func RequireScope(fn RequiredFunc) {
+	requiredMu.Lock()
+	defer requiredMu.Unlock()
+	requiredFuncs = append(requiredFuncs, fn)
+}
+
+// requiredConditions returns the conditions of the required scopes for the context of db
+func requiredConditions(db *gorm.DB) ([]condition, error) {
+	requiredMu.RLock()
+	defer requiredMu.RUnlock()
+	if len(requiredFuncs) == 0 {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	if db.Statement.Context != nil {
+		ctx = db.Statement.Context
+	}
+	conditions := make([]condition, 0, len(requiredFuncs))
+	for _, fn := range requiredFuncs {
+		rule, value, ok := fn(ctx)
+		if !ok || value == nil {
+			return nil, ErrMissingScope
+		}
+		cond, ok, err := parseRule(db, rule, reflect.ValueOf(value))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidTag, rule.Opt)
+		}
+		cond.logic, cond.group, cond.having = LogicAnd, "", false // 必须满足, 不参与 OR 和分组
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// RequireAtLeast makes filter scopes add ErrUnfiltered to db when fewer than n conditions are left after
+// skipping zero values. Conditions of required scopes are not counted, 0 disables the check
+func RequireAtLeast(n int) {
+	requiredMu.Lock()
+	defer requiredMu.Unlock()
+	minConditions = n
+}
+
+// leastConditions returns the number of conditions set by RequireAtLeast
+func leastConditions() int {
+	requiredMu.RLock()
+	defer requiredMu.RUnlock()
+	return minConditions
+}
@@ -0,0 +1,264 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FromRSQL applies an RSQL filter such as age=ge=30;name=like=jo*,status=in=(a,b), where ; is AND,
+// which binds tighter than , for OR, and parentheses group constraints. Selectors must be rule names
+// and the comparison operators (==, !=, =ge=, =in=, =out=, or any =operator=) must be allowed by the rule
+// (Opt and Ops). A * at the start or end of a =like= value matches any characters there. It panics on invalid filters
+func FromRSQL(rules []Rule, filter string) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildRSQL(db, rules, filter)
+	}, false)
+}
+
+// FromRSQLE is like FromRSQL but adds errors to db
+func FromRSQLE(rules []Rule, filter string) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildRSQL(db, rules, filter)
+	}, true)
+}
+
+// buildRSQL builds the condition of an RSQL filter
+func buildRSQL(db *gorm.DB, rules []Rule, filter string) (res result, err error) {
+	res.source = "rsql"
+	if strings.TrimSpace(filter) == "" {
+		return res, nil
+	}
+
+	p := rsqlParser{db: db, rules: ruleMap(rules), s: filter}
+	expr, ok, err := p.or()
+	if err != nil {
+		return res, err
+	}
+	if p.pos < len(p.s) {
+		return res, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	if ok {
+		res.conditions = append(res.conditions, condition{expr: expr})
+	}
+
+	return res, nil
+}
+
+// rsqlOperators are the RSQL comparison operators besides =operator= names
+var rsqlOperators = map[string]string{"==": Eq, "!=": Neq, "=lt=": LT, "=le=": LTE, "=gt=": GT, "=ge=": GTE, "=out=": NotIn}
+
+// rsqlParser is a recursive descent parser of RSQL filters
+type rsqlParser struct {
+	db    *gorm.DB
+	rules map[string]Rule
+	s     string
+	pos   int
+	depth int // 当前的括号嵌套层数
+}
+
+// or parses constraints separated by ,
+func (p *rsqlParser) or() (clause.Expression, bool, error) {
+	var exprs []clause.Expression
+	for {
+		expr, ok, err := p.and()
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			exprs = append(exprs, expr)
+		}
+		if !p.consume(',') {
+			return joinExprs(exprs, LogicOr)
+		}
+	}
+}
+
+// and parses constraints separated by ;
+func (p *rsqlParser) and() (clause.Expression, bool, error) {
+	var exprs []clause.Expression
+	for {
+		expr, ok, err := p.constraint()
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			exprs = append(exprs, expr)
+		}
+		if !p.consume(';') {
+			return joinExprs(exprs, LogicAnd)
+		}
+	}
+}
+
+// constraint parses a comparison or a group in parentheses
+func (p *rsqlParser) constraint() (clause.Expression, bool, error) {
+	if p.consume('(') {
+		if p.depth++; p.depth > maxNesting {
+			return nil, false, p.errorf("nesting deeper than %d", maxNesting)
+		}
+		defer func() { p.depth-- }()
+		expr, ok, err := p.or()
+		if err != nil {
+			return nil, false, err
+		}
+		if !p.consume(')') {
+			return nil, false, p.errorf("expected )")
+		}
+		return expr, ok, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && isIdentifier(p.s[p.pos:p.pos+1]) {
+		p.pos++
+	}
+	name := p.s[start:p.pos]
+	if name == "" {
+		return nil, false, p.errorf("expected a selector")
+	}
+	rule, ok := p.rules[name]
+	if !ok {
+		return nil, false, fmt.Errorf("%w: unknown field %q", ErrInvalidColumn, name)
+	}
+
+	opt, err := p.operator()
+	if err != nil {
+		return nil, false, err
+	}
+	if !allowsOperator(rule, opt) {
+		return nil, false, fmt.Errorf("%w: operator %q is not allowed for %s", ErrInvalidValue, opt, name)
+	}
+	rule.Opt = opt
+
+	values, quoted, err := p.arguments()
+	if err != nil {
+		return nil, false, err
+	}
+	var rfVal reflect.Value
+	switch {
+	case multiValue(opt):
+		rfVal, err = typedValues(rule, values)
+	case len(values) != 1:
+		return nil, false, fmt.Errorf("%w: %s rule requires a single value", ErrInvalidValue, opt)
+	case opt == Like && !quoted:
+		rule.Opt, values[0] = likeWildcard(values[0])
+		rfVal = reflect.ValueOf(values[0])
+	default:
+		rule, rfVal, err = queryValue(rule, values)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	cond, ok, err := valueCondition(p.db, rule, rfVal)
+	return cond.expr, ok, err
+}
+
+// operator parses a comparison operator
+func (p *rsqlParser) operator() (string, error) {
+	for _, op := range []string{"==", "!="} {
+		if strings.HasPrefix(p.s[p.pos:], op) {
+			p.pos += len(op)
+			return rsqlOperators[op], nil
+		}
+	}
+	if !p.consume('=') {
+		return "", p.errorf("expected an operator")
+	}
+	end := strings.IndexByte(p.s[p.pos:], '=')
+	if end < 0 {
+		return "", p.errorf("expected an operator")
+	}
+	name := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	if opt, ok := rsqlOperators["="+name+"="]; ok {
+		return opt, nil
+	}
+	if opt, ok := operatorName(name); ok {
+		return opt, nil
+	}
+	return "", fmt.Errorf("%w: unknown operator %q", ErrInvalidValue, "="+name+"=")
+}
+
+// arguments parses a value or a list of values in parentheses, quoted reports whether a value is quoted
+func (p *rsqlParser) arguments() (values []string, quoted bool, err error) {
+	if !p.consume('(') {
+		v, q, err := p.value()
+		return []string{v}, q, err
+	}
+	for {
+		v, q, err := p.value()
+		if err != nil {
+			return nil, false, err
+		}
+		values = append(values, v)
+		quoted = quoted || q
+		if p.consume(')') {
+			return values, quoted, nil
+		}
+		if !p.consume(',') {
+			return nil, false, p.errorf("expected , or )")
+		}
+	}
+}
+
+// value parses an unreserved or a quoted value, quotes are escaped with a backslash
+func (p *rsqlParser) value() (string, bool, error) {
+	if p.pos < len(p.s) && (p.s[p.pos] == '\'' || p.s[p.pos] == '"') {
+		quote := p.s[p.pos]
+		var sb strings.Builder
+		for p.pos++; p.pos < len(p.s); p.pos++ {
+			c := p.s[p.pos]
+			if c == '\\' && p.pos+1 < len(p.s) {
+				p.pos++
+				c = p.s[p.pos]
+			} else if c == quote {
+				p.pos++
+				return sb.String(), true, nil
+			}
+			sb.WriteByte(c)
+		}
+		return "", false, p.errorf("unterminated string")
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(";,()'\"", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", false, p.errorf("expected a value")
+	}
+	return p.s[start:p.pos], false, nil
+}
+
+// consume skips c if it is the next character
+func (p *rsqlParser) consume(c byte) bool {
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// errorf returns a syntax error at the current position
+func (p *rsqlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: rsql: %s at position %d", ErrInvalidValue, fmt.Sprintf(format, args...), p.pos)
+}
+
+// likeWildcard returns the like operator matching the * at the start or end of value, and value without them
+func likeWildcard(value string) (string, string) {
+	prefix, suffix := strings.HasPrefix(value, "*"), strings.HasSuffix(value, "*")
+	switch {
+	case prefix && suffix && len(value) > 1:
+		return Like, value[1 : len(value)-1]
+	case prefix:
+		return EndsWith, value[1:]
+	case suffix:
+		return StartsWith, value[:len(value)-1]
+	default:
+		return Like, value
+	}
+}
@@ -1,228 +1,2362 @@
+// Package filter builds gorm scopes from the filter tags of structs, query strings and query languages.
+//
+// Scopes panic on invalid tags and values, their E variants such as FilterE add the error to db instead.
+// The Set functions change package-wide settings without synchronization, call them during initialization.
 package filter
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 const (
-	Eq        = "="
-	Like      = "like"
-	Rlike     = "rlike"
-	GT        = ">"
-	LT        = "<"
-	GTE       = ">="
-	LTE       = "<="
-	In        = "in"
-	DateRange = "date_range"
+	Eq            = "="
+	Neq           = "!="
+	Like          = "like"
+	NotLike       = "not_like"
+	StartsWith    = "starts_with"
+	EndsWith      = "ends_with"
+	ILike         = "ilike" // 忽略大小写, 非 postgres 使用 lower() 兼容
+	Rlike         = "rlike"
+	Regexp        = "regexp" // postgres 使用 ~, 其他使用 regexp
+	GT            = ">"
+	LT            = "<"
+	GTE           = ">="
+	LTE           = "<="
+	In            = "in"
+	NotIn         = "not_in"
+	Between       = "between" // 两个元素的切片或 Range, 一个边界为空时只比较另一个
+	DateRange     = "date_range"
+	DatetimeRange = "datetime_range" // 完整时间, 不补全时分秒
+	LastDays      = "last_days"      // 最近 N 天, 字段值为 N
+	LastHours     = "last_hours"     // 最近 N 小时, 字段值为 N
+	IsNull        = "is_null"        // 忽略字段值
+	NotNull       = "not_null"       // 忽略字段值
+	JSONContains  = "json_contains"  // 字段值序列化为 JSON
+	ArrayContains = "array_contains" // postgres 数组包含
+	ArrayAny      = "array_any"      // postgres 数组任一元素相等
+	Exists        = "exists"         // 关联表存在满足条件的记录, 需要 table, fk, ref
+	ColCmp        = "col_cmp"        // 字段值为另一列名, 比较符由 cmp 指定
+	Unscoped      = "unscoped"       // 布尔字段为 true 时包含软删除的记录, 不生成条件
+	Raw           = "raw"            // 使用 sql 指定的 SQL 片段, 字段值填充其中每个 ? 占位符
+	Bool3         = "bool3"          // 三态布尔, 字符串 true / false 比较布尔列, 空字符串忽略
+)
+
+var (
+	ErrInvalidTag    = errors.New("invalid filter tag")
+	ErrInvalidValue  = errors.New("invalid filter value")
+	ErrInvalidColumn = errors.New("invalid filter column")
+)
+
+// OperatorFunc builds the condition and its parameters for a custom operator,
+// rule.Name is already replaced by rule.Column and qualified with rule.Alias or rule.Table
+type OperatorFunc func(rule Rule, v reflect.Value) (string, []any)
+
+var (
+	operatorsMu sync.RWMutex
+	operators   = make(map[string]OperatorFunc)
+)
+
+// RegisterOperator registers a custom operator, built-in operators take precedence
+func RegisterOperator(name string, fn OperatorFunc) {
+	operatorsMu.Lock()
+	defer operatorsMu.Unlock()
+	operators[name] = fn
+}
+
+// lookupOperator returns the custom operator registered with name
+func lookupOperator(name string) (OperatorFunc, bool) {
+	operatorsMu.RLock()
+	defer operatorsMu.RUnlock()
+	fn, ok := operators[name]
+	return fn, ok
+}
+
+// builtinOperators are the operators handled by parseRule
+var builtinOperators = map[string]bool{
+	Eq: true, Neq: true, "neq": true, Like: true, NotLike: true, StartsWith: true, EndsWith: true, ILike: true,
+	Rlike: true, Regexp: true, GT: true, LT: true, GTE: true, LTE: true, In: true, NotIn: true, Between: true,
+	DateRange: true, DatetimeRange: true, LastDays: true, LastHours: true, IsNull: true, NotNull: true,
+	JSONContains: true, ArrayContains: true, ArrayAny: true, Exists: true, ColCmp: true, Unscoped: true, Raw: true,
+	Bool3: true,
+}
+
+// now returns the current time
+var now = time.Now
+
+const (
+	LogicAnd = "and"
+	LogicOr  = "or"
+)
+
+// column types of rules, MultiSearch skips the rules a keyword cannot be converted to
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeUint   = "uint"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+	TypeTime   = "time" // 使用 Layout 解析, 默认 RFC 3339、日期时间或日期
+	TypeUUID   = "uuid" // =、!= 和 in 的字符串值必须是 UUID
 )
 
 // Rule represents a search rule for a field in a struct
 type Rule struct {
-	Name    string // 字段名
-	Opt     string // 操作
-	Table   string // 表名
-	UseZero bool   // 是否使用零值
+	Name       string            `json:"name" yaml:"name"`                                   // 字段名
+	Opt        string            `json:"opt,omitempty" yaml:"opt,omitempty"`                 // 操作
+	Column     string            `json:"column,omitempty" yaml:"column,omitempty"`           // 数据库列名, 默认使用字段名
+	Columns    []string          `json:"columns,omitempty" yaml:"columns,omitempty"`         // 多个数据库列, 任一列匹配即可, 如 columns:name,email
+	Table      string            `json:"table,omitempty" yaml:"table,omitempty"`             // 表名
+	Alias      string            `json:"alias,omitempty" yaml:"alias,omitempty"`             // 表在查询中的别名, 如 JOIN users u 的 u, 设置后用于限定列名
+	UseZero    bool              `json:"use_zero,omitempty" yaml:"use_zero,omitempty"`       // 是否使用零值
+	ZeroKinds  []string          `json:"zero_kinds,omitempty" yaml:"zero_kinds,omitempty"`   // 使用零值的值类型: string / int / uint / float / bool / time, 如 use_zero:int|bool
+	EmptyNone  bool              `json:"empty_none,omitempty" yaml:"empty_none,omitempty"`   // in 规则的空切片不匹配任何行 (1 = 0), 而不是忽略条件, nil 切片仍然忽略
+	Logic      string            `json:"logic,omitempty" yaml:"logic,omitempty"`             // 逻辑关系: and / or, 默认 and
+	Group      string            `json:"group,omitempty" yaml:"group,omitempty"`             // 分组名, 同组条件用括号包裹
+	GroupLogic string            `json:"group_logic,omitempty" yaml:"group_logic,omitempty"` // 分组与其他条件的逻辑关系: and / or, 默认 and
+	Not        bool              `json:"not,omitempty" yaml:"not,omitempty"`                 // 条件取反, 有分组时对整个分组取反, 如 NOT (a AND b)
+	ForeignKey string            `json:"fk,omitempty" yaml:"fk,omitempty"`                   // 关联表外键, exists 使用
+	References string            `json:"ref,omitempty" yaml:"ref,omitempty"`                 // 主表关联字段, exists 使用, 如 users.id
+	Cmp        string            `json:"cmp,omitempty" yaml:"cmp,omitempty"`                 // 比较符, col_cmp 使用, 默认 =
+	Cols       []string          `json:"cols,omitempty" yaml:"cols,omitempty"`               // col_cmp 允许比较的列, 如 cols:start_at,end_at, 字段值必须是其中之一
+	SQL        string            `json:"-" yaml:"-"`                                         // SQL 片段, raw 使用, 如 JSON_EXTRACT(meta, '$.level') = ?, 不可来自用户输入, 不序列化
+	Rel        string            `json:"rel,omitempty" yaml:"rel,omitempty"`                 // 模型的 has one / has many / belongs to 关联名, 如 Orders, 按关联表的列过滤主表
+	Join       string            `json:"join,omitempty" yaml:"join,omitempty"`               // 关联条件, 结构体字段使用, 如 user_id=id
+	Distinct   bool              `json:"distinct,omitempty" yaml:"distinct,omitempty"`       // 一对多关联, join 后使用 DISTINCT 去除重复的主表行, 结构体字段使用
+	Layout     string            `json:"layout,omitempty" yaml:"layout,omitempty"`           // 时间格式, 用于解析字符串值, 或将 time.Time 格式化为字符串
+	TZ         string            `json:"tz,omitempty" yaml:"tz,omitempty"`                   // 时区, date_range 日期所在时区, 如 Asia/Shanghai
+	HalfOpen   bool              `json:"half_open,omitempty" yaml:"half_open,omitempty"`     // date_range 使用左闭右开区间, 上界为次日零点
+	Wildcard   bool              `json:"wildcard,omitempty" yaml:"wildcard,omitempty"`       // like 类操作保留值中的通配符 % 和 _, 默认转义
+	Ops        []string          `json:"ops,omitempty" yaml:"ops,omitempty"`                 // 允许在查询参数中指定的运算符, 如 ?age=gte:30, 为空时不解析
+	Type       string            `json:"type,omitempty" yaml:"type,omitempty"`               // 列类型: string / int / uint / float / bool / time / uuid, MultiSearch 跳过类型不符的关键词
+	Transform  []string          `json:"transform,omitempty" yaml:"transform,omitempty"`     // 绑定前依次应用的变换, 如 trim, lower, 见 RegisterTransform
+	Validators []string          `json:"validate,omitempty" yaml:"validate,omitempty"`       // 生成条件前依次运行的校验, 如 uuid, positive, 见 RegisterValidator
+	Validate   func(v any) error `json:"-" yaml:"-"`                                         // 在 Validators 之后运行的校验, 仅用于代码中定义的规则
+	Map        map[string]any    `json:"map,omitempty" yaml:"map,omitempty"`                 // 取值映射, 将接口的枚举值转换为数据库的值, 如 active=1|inactive=0
+	Default    any               `json:"default,omitempty" yaml:"default,omitempty"`         // 字段为零值或缺失时使用的值, 为 nil 时忽略该字段
+	When       string            `json:"when,omitempty" yaml:"when,omitempty"`               // 仅当同一结构体的另一字段为指定值时生效, 如 type=order 或 type=order|invoice
+	Trusted    bool              `json:"-" yaml:"-"`                                         // 信任的规则, 不校验字段名和表名, 仅用于非用户输入的规则, 不序列化
+	Having     bool              `json:"having,omitempty" yaml:"having,omitempty"`           // 条件放入 HAVING 而非 WHERE, 用于聚合列的别名, 如 order_count
+
+	special string   // 分页、排序等特殊字段: page / page_size / sort / fields / unscoped, 不生成过滤条件
+	allow   []string // sort 字段允许排序的列, fields 字段允许查询的列
+}
+
+// RangeRule returns the rules of a bounded range on column for Search, the lower bound is read
+// from the field named min_<column> with >= and the upper bound from max_<column> with <=
+func RangeRule(column string) []Rule {
+	return []Rule{
+		{Name: "min_" + column, Column: column, Opt: GTE},
+		{Name: "max_" + column, Column: column, Opt: LTE},
+	}
+}
+
+// Range is the value of a between rule
+type Range struct {
+	Min interface{} `json:"min"`
+	Max interface{} `json:"max"`
 }
 
-// Filter applies filter rules to the given dest struct
+// condition is a generated SQL condition
+type condition struct {
+	expr       clause.Expression
+	name       string // 规则名, 用于日志
+	column     string // 条件的列, 多列以逗号连接, 用于跟踪
+	opt        string // 条件的运算符, 用于跟踪
+	logic      string
+	group      string
+	groupLogic string
+	not        bool // 是否取反, 分组条件对整个分组取反
+	having     bool // 是否放入 HAVING
+}
+
+// Filter applies filter rules to the given dest struct, it panics on invalid tags or values.
+// A slice of structs matches any of its elements, e.g. (name = ? AND age = ?) OR (name = ? AND age = ?)
 func Filter(dest any) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		rv := reflect.ValueOf(dest)
+	return std.Filter(dest)
+}
+
+// FilterE is like Filter but adds errors to db
+func FilterE(dest any) func(*gorm.DB) *gorm.DB {
+	return std.FilterE(dest)
+}
+
+// FilterAny is like Filter but joins the conditions with OR, matching the rows satisfying any of the given criteria.
+// Groups are OR'd as a whole, the conditions of RequireScope still restrict every row
+func FilterAny(dest any) func(*gorm.DB) *gorm.DB {
+	return std.FilterAny(dest)
+}
 
-		if rv.Kind() == reflect.Ptr {
-			rv = rv.Elem()
+// FilterAnyE is like FilterAny but adds errors to db
+func FilterAnyE(dest any) func(*gorm.DB) *gorm.DB {
+	return std.FilterAnyE(dest)
+}
+
+// Search applies search rules to the given dest struct, it panics on invalid values
+func Search(rules []Rule, dest any) func(*gorm.DB) *gorm.DB {
+	return std.Search(rules, dest)
+}
+
+// SearchE is like Search but adds errors to db
+func SearchE(rules []Rule, dest any) func(*gorm.DB) *gorm.DB {
+	return std.SearchE(rules, dest)
+}
+
+// FilterMap applies the rules to the values keyed by rule name, e.g. a decoded JSON payload,
+// values without a rule are ignored and zero values of present keys are used. It panics on invalid values
+func FilterMap(rules []Rule, values map[string]any) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildMap(db, rules, values)
+	}, false)
+}
+
+// FilterMapE is like FilterMap but adds errors to db
+func FilterMapE(rules []Rule, values map[string]any) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		return buildMap(db, rules, values)
+	}, true)
+}
+
+// Conditions applies conditions built outside the package, such as by the Scope methods of gormfilter-gen,
+// like the conditions of Filter: with RequireScope, RequireAtLeast, the logs and stats, and grouping the
+// conditions of db chained with Or. The conditions in ors are OR'd together as one group, source is
+// reported by OnApply like the type of a filter struct
+func Conditions(source string, ands, ors []clause.Expression) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		res := result{source: source, conditions: make([]condition, 0, len(ands)+len(ors))}
+		for _, expr := range ands {
+			res.conditions = append(res.conditions, condition{expr: expr})
 		}
-		if rv.Kind() != reflect.Struct {
-			return db
+		for _, expr := range ors {
+			res.conditions = append(res.conditions, condition{expr: expr, logic: LogicOr})
 		}
+		return res, nil
+	}, true)
+}
 
-		destMap := make(map[string]reflect.Value)
-		var rules []Rule
-		for i := 0; i < rv.NumField(); i++ {
-			filterTagStr := rv.Type().Field(i).Tag.Get("filter")
-			filterTagStr = strings.Trim(filterTagStr, " ;,") // 去除首尾多余的逗号和分号
-			if filterTagStr == "" || filterTagStr == "-" {   // 忽略没有filter标签的字段或filter:"-"的字段
-				continue
-			}
+// MultiSearch applies search rules to the given dest string, it panics on invalid values.
+// SetMultiSearchTokens makes it match every word of dest separately
+func MultiSearch(rules []Rule, dest string) func(*gorm.DB) *gorm.DB {
+	return std.MultiSearch(rules, dest)
+}
 
-			var rule Rule
-			rule.Name = strings.TrimSpace(removeOmitempty(rv.Type().Field(i).Tag.Get("json")))
-			destMap[rule.Name] = rv.Field(i)
-
-			filterTags := strings.Split(filterTagStr, ";")
-			for _, filterTag := range filterTags {
-				kv := strings.Split(filterTag, ":")
-				k := strings.TrimSpace(kv[0])
-				v := strings.TrimSpace(kv[1])
-
-				switch k {
-				case "opt":
-					rule.Opt = v
-				case "table":
-					rule.Table = v
-				case "use_zero", "useZero": // 兼容小驼峰和蛇形名称
-					b, err := strconv.ParseBool(v)
-					if err != nil {
-						panic(err)
-					}
-					rule.UseZero = b
-				}
+// MultiSearchE is like MultiSearch but adds errors to db
+func MultiSearchE(rules []Rule, dest string) func(*gorm.DB) *gorm.DB {
+	return std.MultiSearchE(rules, dest)
+}
+
+// result is what a filter adds to the query
+type result struct {
+	conditions []condition
+	joins      []*join
+	page       *pagination            // 结构体的分页字段, 为空时不分页
+	orders     []clause.OrderByColumn // 结构体的排序字段
+	orderBy    clause.Expression      // 表达式排序, 如 MultiSearch 的相关度, 替换查询的其他排序
+	fields     []string               // 结构体的查询列字段
+	unscoped   bool                   // 是否包含软删除的记录
+	skipped    []SkippedField         // 没有生成条件的字段, Diagnose 时记录
+	source     string                 // 条件的来源, 见 FilterStats.Source
+}
+
+// scope builds the filter and adds it to the query,
+// errors are added to db when addError is true, otherwise they panic
+func scope(build func(db *gorm.DB) (result, error), addError bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		res, err := build(db)
+		if err != nil {
+			logResult(res, err)
+			if !addError {
+				panic(err)
 			}
-			rules = append(rules, rule)
+			_ = db.AddError(err)
+			return db
+		}
+		if skipped := skippedFields(db); skipped != nil {
+			*skipped = append(*skipped, res.skipped...)
 		}
 
-		if len(rules) == 0 {
+		if least := leastConditions(); len(res.conditions) < least { // 防止意外查询整张表
+			_ = db.AddError(fmt.Errorf("%w: %d of %d", ErrUnfiltered, len(res.conditions), least))
+			return db
+		}
+		required, err := requiredConditions(db)
+		if err != nil { // 缺少必需的条件时总是让查询失败
+			_ = db.AddError(err)
 			return db
 		}
+		if len(required) > 0 {
+			res.conditions = append(required, res.conditions...)
+		}
+		logResult(res, nil)
+		annotateSpan(db, res)
+		reportStats(res)
+
+		if res.unscoped {
+			db = db.Unscoped()
+		}
+		for _, j := range res.joins {
+			db.Joins(j.sql(), j.vars(db)...)
+			if j.distinct { // 一对多关联会重复主表行
+				db.Distinct()
+			}
+		}
+		for _, order := range res.orders {
+			db.Order(order)
+		}
+		if res.orderBy != nil {
+			db.Order(clause.OrderBy{Expression: res.orderBy})
+		}
+		if len(res.fields) > 0 {
+			db.Select(res.fields)
+		}
+		if res.page != nil {
+			res.page.apply(db)
+		}
+		where, having := splitHaving(res.conditions)
+		if len(where) > 0 {
+			addWhere(db, joinConditions(where))
+		}
+		if len(having) > 0 {
+			db.Having(joinConditions(having))
+		}
 
-		var conditions []string
-		var params []interface{}
+		return db
+	}
+}
 
-		for _, rule := range rules {
-			rfVal := destMap[rule.Name] // ensure the field exists
+// anyOf makes the conditions of res OR'd with each other, groups as a whole
+func anyOf(res result, err error) (result, error) {
+	for i := range res.conditions {
+		if res.conditions[i].group != "" {
+			res.conditions[i].groupLogic = LogicOr
+		} else {
+			res.conditions[i].logic = LogicOr
+		}
+	}
+	return res, err
+}
 
-			// Skip zero values and empty slices if UseZero is false
-			emptySlice := rfVal.Kind() == reflect.Slice && rfVal.Len() == 0 // 兼容空切片
-			if (rfVal.IsZero() || emptySlice) && !rule.UseZero {
-				continue
-			}
+// buildFilter builds the conditions and joins from the filter tags of the dest struct, or of each element
+// of a slice of structs
+func (e *Engine) buildFilter(db *gorm.DB, dest any) (res result, err error) {
+	rv := reflect.ValueOf(dest)
 
-			conditions, params = parseRule(rule, rfVal, conditions, params)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	switch {
+	case rv.Kind() == reflect.Struct:
+		var rules []fieldRule
+		if rules, err = e.fieldRules(rv.Type()); err != nil {
+			return res, err
 		}
+		res, err = buildRules(db, rules, rv)
+	case (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && indirectType(rv.Type().Elem()).Kind() == reflect.Struct:
+		res, err = e.buildFilters(db, rv)
+	default:
+		return res, nil
+	}
+	if err == nil && skippedFields(db) != nil {
+		res.skipped = append(e.untaggedFields(rv.Type()), res.skipped...)
+	}
+	return res, err
+}
 
-		queryStr := strings.Join(conditions, " AND ")
-		db.Where(queryStr, params...)
+// buildFilters builds the conditions of a slice of filter structs, such as a batch of composite keys,
+// joining the conditions of each element with AND and the elements with OR. Nil elements and elements
+// without conditions are skipped, having rules are not supported and the page, sort and fields of
+// the elements are ignored
+func (e *Engine) buildFilters(db *gorm.DB, rv reflect.Value) (res result, err error) {
+	rules, err := e.fieldRules(indirectType(rv.Type().Elem()))
+	if err != nil {
+		return res, err
+	}
+	res.source = "[]" + indirectType(rv.Type().Elem()).String()
 
-		return db
+	exprs := make([]clause.Expression, 0, rv.Len())
+	var joined map[*join]bool
+	for i := 0; i < rv.Len(); i++ {
+		ev := rv.Index(i)
+		if ev.Kind() == reflect.Ptr {
+			if ev.IsNil() {
+				continue
+			}
+			ev = ev.Elem()
+		}
+		elem, err := buildRules(db, rules, ev)
+		if err != nil {
+			return res, fmt.Errorf("element %d: %w", i, err)
+		}
+		for _, s := range elem.skipped {
+			s.Field = fmt.Sprintf("[%d].%s", i, s.Field)
+			res.skipped = append(res.skipped, s)
+		}
+		where, having := splitHaving(elem.conditions)
+		if len(having) > 0 {
+			return res, fmt.Errorf("element %d: %w: having rules are not supported in slices", i, ErrInvalidTag)
+		}
+		if len(where) == 0 { // 没有条件的元素会匹配所有行
+			continue
+		}
+		exprs = append(exprs, joinConditions(where))
+		for _, j := range elem.joins { // 各元素的关联表相同, 只 join 一次
+			if joined == nil {
+				joined = make(map[*join]bool)
+			}
+			if !joined[j] {
+				joined[j] = true
+				res.joins = append(res.joins, j)
+			}
+		}
 	}
+	if expr, ok, _ := joinExprs(exprs, LogicOr); ok {
+		res.conditions = append(res.conditions, condition{expr: expr})
+	}
+	return res, nil
 }
 
-// Search applies search rules to the given dest struct
-func Search(rules []Rule, dest any) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		rv := reflect.ValueOf(dest)
+// buildRules builds the conditions and joins of the parsed rules from the struct value rv
+func buildRules(db *gorm.DB, rules []fieldRule, rv reflect.Value) (res result, err error) {
+	res.source = rv.Type().String()
+	res.conditions = make([]condition, 0, len(rules))
+	var joined map[*join]bool
+	for _, fr := range rules {
+		rule := fr.Rule
+		rfVal, err := rv.FieldByIndexErr(fr.index)
+		if err != nil { // 嵌入的结构体指针为 nil
+			res.skip(fr.field, rule.Name, SkipNilStruct)
+			continue
+		}
+		if rule.special != "" {
+			if !rfVal.IsZero() { // 未指定的分页等字段不校验
+				if err := validateValue(rule, rfVal); err != nil {
+					return res, err
+				}
+			}
+			if err := res.special(rule, rfVal); err != nil {
+				return res, fmt.Errorf("field %s: %w", rule.Name, err)
+			}
+			continue
+		}
+		if !whenHolds(rule, func(string) (reflect.Value, bool) {
+			v, err := rv.FieldByIndexErr(fr.when)
+			return v, err == nil
+		}) {
+			res.skip(fr.field, rule.Name, SkipWhen)
+			continue
+		}
 
-		if rv.Kind() == reflect.Ptr {
-			rv = rv.Elem()
+		cond, ok, err := valueCondition(db, rule, rfVal)
+		if err != nil {
+			return res, err
 		}
-		if rv.Kind() != reflect.Struct {
-			return db
+		if !ok {
+			res.skip(fr.field, rule.Name, skipReason(rule, rfVal))
+			continue
 		}
+		res.conditions = append(res.conditions, cond)
+		if fr.join == nil {
+			continue
+		}
+		if joined == nil {
+			joined = make(map[*join]bool)
+		}
+		n := len(res.joins) // 外层的关联表需要先 join, 追加后反转
+		for j := fr.join; j != nil && !joined[j]; j = j.parent {
+			joined[j] = true
+			res.joins = append(res.joins, j)
+		}
+		for i, k := n, len(res.joins)-1; i < k; i, k = i+1, k-1 {
+			res.joins[i], res.joins[k] = res.joins[k], res.joins[i]
+		}
+	}
 
-		if len(rules) == 0 {
-			return db
+	return res, nil
+}
+
+// skip records a field or rule that added no condition
+func (res *result) skip(field, name, reason string) {
+	res.skipped = append(res.skipped, SkippedField{Field: field, Name: name, Reason: reason})
+}
+
+// specialFields are the tag keys of fields that are not filter conditions, by their names in the tag
+var specialFields = map[string]string{"page": "page", "page_size": "page_size", "pageSize": "page_size", "sort": "sort", "fields": "fields"}
+
+// special reads the value of a page, page_size, sort, fields or unscoped field into the result
+func (res *result) special(rule Rule, rfVal reflect.Value) error {
+	if (rule.special == "page" || rule.special == "page_size") && res.page == nil {
+		res.page = &pagination{}
+	}
+	if v, valid, ok := nullableValue(rfVal); ok {
+		rfVal = v
+		if !valid { // 未设置的值按空值处理
+			rfVal = reflect.Value{}
 		}
+	}
+	for rfVal.Kind() == reflect.Ptr {
+		if rfVal = rfVal.Elem(); !rfVal.IsValid() {
+			break
+		}
+	}
 
-		// create a map of dest struct fields to their values
-		destMap := make(map[string]reflect.Value)
-		for i := 0; i < rv.NumField(); i++ {
-			jsonField := strings.TrimSpace(removeOmitempty(rv.Type().Field(i).Tag.Get("json")))
-			if jsonField != "" {
-				destMap[jsonField] = rv.Field(i)
+	if rule.special == "sort" {
+		var orderBy string
+		if rfVal.IsValid() {
+			if rfVal.Kind() != reflect.String {
+				return fmt.Errorf("%w: sort requires a string value", ErrInvalidValue)
 			}
+			orderBy = rfVal.String()
+		}
+		orders, err := sortOrders(orderBy, rule.allow)
+		res.orders = append(res.orders, orders...)
+		return err
+	}
+	if rule.special == Unscoped {
+		if !rfVal.IsValid() {
+			return nil
+		}
+		if rfVal.Kind() != reflect.Bool {
+			return fmt.Errorf("%w: unscoped requires a bool value", ErrInvalidValue)
+		}
+		res.unscoped = res.unscoped || rfVal.Bool()
+		return nil
+	}
+	if rule.special == "fields" {
+		if !rfVal.IsValid() {
+			return nil
 		}
+		fields, err := selectFields(rfVal, rule.allow)
+		res.fields = append(res.fields, fields...)
+		return err
+	}
+
+	if !rfVal.IsValid() || rfVal.IsZero() {
+		return nil
+	}
+	n, err := intValue(rfVal, rule.special)
+	if err != nil {
+		return err
+	}
+	if rule.special == "page" {
+		res.page.page = int(n)
+	} else {
+		res.page.pageSize = int(n)
+	}
+	return nil
+}
 
-		var conditions []string
-		var params []interface{}
+// fieldRule is a rule parsed from the filter tag of a struct field
+type fieldRule struct {
+	Rule
+	field string // 结构体字段名, 嵌套结构体的字段以 . 连接
+	index []int  // 字段索引, 包含嵌入结构体的索引
+	join  *join  // 所属的关联结构体
+	when  []int  // when 引用的字段索引
+}
 
-		for _, rule := range rules {
-			rfVal, ok := destMap[rule.Name]
-			if !ok {
-				continue
-			}
+// join is the table joined for the fields of a nested struct
+type join struct {
+	table      string
+	alias      string // 表的别名, 为空时使用表名
+	foreignKey string // 关联表字段
+	references string // 主表字段
+	parent     *join  // 外层的关联结构体, 为空时主表为当前表
+	distinct   bool   // 一对多关联, 需要去重
+}
 
-			// Skip zero values if UseZero is false
-			if rfVal.IsZero() && !rule.UseZero {
-				continue
+// sql returns the join clause with placeholders for the vars
+func (j *join) sql() string {
+	return "join ? on ? = ?"
+}
+
+// vars returns the table and columns of the join clause, tables with the table prefix of db
+func (j *join) vars(db *gorm.DB) []interface{} {
+	ref := clause.Column{Table: clause.CurrentTable, Name: j.references}
+	if j.parent != nil {
+		ref.Table = j.parent.qualifier(db)
+	}
+	if refTable, column, ok := strings.Cut(j.references, "."); ok {
+		ref = clause.Column{Table: prefixTable(db, refTable), Name: column}
+	}
+	table := clause.Table{Name: prefixTable(db, j.table), Alias: j.alias}
+	return []interface{}{table, clause.Column{Table: j.qualifier(db), Name: j.foreignKey}, ref}
+}
+
+// qualifier returns the qualifier of the columns of the joined table, its alias or its prefixed name
+func (j *join) qualifier(db *gorm.DB) string {
+	if j.alias != "" {
+		return j.alias
+	}
+	return prefixTable(db, j.table)
+}
+
+// parseFieldRules parses the filter tags of the fields of rt, including the fields of embedded structs
+func (e *Engine) parseFieldRules(rt reflect.Type) ([]fieldRule, error) {
+	var rules []fieldRule
+	for _, field := range reflect.VisibleFields(rt) {
+		filterTagStr := field.Tag.Get(e.tagKey)
+		filterTagStr = strings.Trim(filterTagStr, " ;,") // 去除首尾多余的逗号和分号
+		if filterTagStr == "" || filterTagStr == "-" {   // 忽略没有filter标签的字段或filter:"-"的字段
+			continue
+		}
+
+		rule, err := parseTag(filterTagStr, e.strict)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if e.strict { // 严格模式下报告未知的运算符等, 而不是忽略
+			if err := validateRule(rule); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+		if rule.Join != "" {
+			nested, err := e.parseJoinRules(field, rule)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, nested...)
+			continue
+		}
+		rule.Name = e.fieldName(field)
+		fr := fieldRule{Rule: e.rule(rule), field: field.Name, index: field.Index}
+		if rule.When != "" {
+			if fr.when, err = e.whenIndex(rt, rule.When); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
 			}
+		}
+		rules = append(rules, fr)
+	}
+
+	return rules, nil
+}
 
-			conditions, params = parseRule(rule, rfVal, conditions, params)
+// whenIndex returns the index of the field of rt named by a when option like type=order
+func (e *Engine) whenIndex(rt reflect.Type, when string) ([]int, error) {
+	name, _, ok := strings.Cut(when, "=")
+	if !ok {
+		return nil, fmt.Errorf("%w: when: %q", ErrInvalidTag, when)
+	}
+	name = strings.TrimSpace(name)
+	for _, field := range reflect.VisibleFields(rt) {
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			continue
 		}
+		if field.IsExported() && e.fieldName(field) == name {
+			return field.Index, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: when: no field %q", ErrInvalidTag, name)
+}
 
-		queryStr := strings.Join(conditions, " AND ")
-		db.Where(queryStr, params...)
+// whenHolds reports whether the field named by the when option of rule holds one of its values,
+// value returns the value of a field by name. Rules without when always hold
+func whenHolds(rule Rule, value func(name string) (reflect.Value, bool)) bool {
+	if rule.When == "" {
+		return true
+	}
+	name, want, _ := strings.Cut(rule.When, "=")
+	rfVal, ok := value(strings.TrimSpace(name))
+	if !ok {
+		return false
+	}
+	if v, valid, ok := nullableValue(rfVal); ok {
+		if !valid {
+			return false
+		}
+		rfVal = v
+	}
+	for rfVal.Kind() == reflect.Ptr || rfVal.Kind() == reflect.Interface {
+		if rfVal.IsNil() {
+			return false
+		}
+		rfVal = rfVal.Elem()
+	}
 
-		return db
+	got := fmt.Sprint(rfVal.Interface())
+	for _, v := range strings.Split(want, "|") {
+		if strings.TrimSpace(v) == got {
+			return true
+		}
 	}
+	return false
 }
 
-// MultiSearch applies search rules to the given dest string
-func MultiSearch(rules []Rule, dest string) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		dest = strings.TrimSpace(dest)
-		if dest == "" {
-			return db
+// parseJoinRules parses the rules of a nested struct field tagged like "table:profiles;join:user_id=id",
+// its fields become conditions on the joined table
+func (e *Engine) parseJoinRules(field reflect.StructField, rule Rule) ([]fieldRule, error) {
+	rt := indirectType(field.Type)
+	foreignKey, references, ok := strings.Cut(rule.Join, "=")
+	if rt.Kind() != reflect.Struct || rule.Table == "" || !ok {
+		return nil, fmt.Errorf("field %s: %w: join requires a struct field, table and join like user_id=id", field.Name, ErrInvalidTag)
+	}
+
+	j := &join{
+		table:      rule.Table,
+		alias:      rule.Alias,
+		foreignKey: strings.TrimSpace(foreignKey),
+		references: strings.TrimSpace(references),
+		distinct:   rule.Distinct,
+	}
+	for _, ident := range []string{j.table, j.foreignKey, j.references} {
+		if !isIdentifier(ident) {
+			return nil, fmt.Errorf("field %s: %w: %q", field.Name, ErrInvalidColumn, ident)
 		}
-		if len(rules) == 0 {
-			return db
+	}
+	if j.alias != "" && !isIdentifier(j.alias) {
+		return nil, fmt.Errorf("field %s: %w: %q", field.Name, ErrInvalidColumn, j.alias)
+	}
+
+	rules, err := e.parseFieldRules(rt)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	for i := range rules {
+		if rules[i].Table == "" {
+			rules[i].Table, rules[i].Alias = j.table, j.alias
+		}
+		rules[i].field = field.Name + "." + rules[i].field
+		rules[i].index = append(append([]int{}, field.Index...), rules[i].index...)
+		if rules[i].when != nil {
+			rules[i].when = append(append([]int{}, field.Index...), rules[i].when...)
+		}
+		if rules[i].join == nil {
+			rules[i].join = j
+			continue
 		}
+		outer := rules[i].join
+		for outer.parent != nil {
+			outer = outer.parent
+		}
+		if outer != j {
+			outer.parent = j
+		}
+	}
+
+	return rules, nil
+}
 
-		rfVal := reflect.ValueOf(dest)
-		var conditions []string
-		var params []interface{}
+// parseTag parses a filter tag like "opt:like;table:users" into a rule, unknown keys are rejected in strict mode
+func parseTag(filterTagStr string, strict bool) (Rule, error) {
+	var rule Rule
+	var defaultValue *string
+	filterTags := strings.Split(filterTagStr, ";")
+	for _, filterTag := range filterTags {
+		kv := strings.SplitN(filterTag, ":", 2)
+		if special, ok := specialFields[strings.TrimSpace(filterTag)]; ok && len(kv) == 1 {
+			rule.special = special
+			continue
+		}
+		if len(kv) != 2 {
+			return rule, fmt.Errorf("%w: %q", ErrInvalidTag, filterTag)
+		}
+		k := strings.TrimSpace(kv[0])
+		v := strings.TrimSpace(kv[1])
 
-		for _, rule := range rules {
-			conditions, params = parseRule(rule, rfVal, conditions, params)
+		switch k {
+		case "opt":
+			rule.Opt = v
+		case "table":
+			rule.Table = v
+		case "alias":
+			rule.Alias = v
+		case "column":
+			rule.Column = v
+		case "columns":
+			for _, column := range strings.Split(v, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					rule.Columns = append(rule.Columns, column)
+				}
+			}
+		case "use_zero", "useZero": // 兼容小驼峰和蛇形名称
+			if b, err := strconv.ParseBool(v); err == nil {
+				rule.UseZero = b
+				break
+			}
+			for _, kind := range strings.Split(v, "|") { // 按值类型使用零值, 如 use_zero:int|bool
+				if kind = strings.TrimSpace(kind); !zeroKinds[kind] {
+					return rule, fmt.Errorf("%w: use_zero: unknown kind %q", ErrInvalidTag, kind)
+				}
+				rule.ZeroKinds = append(rule.ZeroKinds, kind)
+			}
+		case "empty_none", "emptyNone":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return rule, fmt.Errorf("%w: empty_none: %v", ErrInvalidTag, err)
+			}
+			rule.EmptyNone = b
+		case "logic":
+			rule.Logic = strings.ToLower(v)
+		case "group":
+			rule.Group = v
+		case "group_logic", "groupLogic":
+			rule.GroupLogic = strings.ToLower(v)
+		case "fk":
+			rule.ForeignKey = v
+		case "ref":
+			rule.References = v
+		case "cmp":
+			rule.Cmp = v
+		case "cols": // 列名列表与 columns 和 allow 一样以逗号分隔
+			for _, column := range strings.Split(v, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					rule.Cols = append(rule.Cols, column)
+				}
+			}
+		case "sql":
+			rule.SQL = v
+		case "join":
+			rule.Join = v
+		case "rel":
+			rule.Rel = v
+		case "type":
+			rule.Type = v
+		case "layout":
+			rule.Layout = v
+		case "tz":
+			rule.TZ = v
+		case "half_open", "halfOpen":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return rule, fmt.Errorf("%w: half_open: %v", ErrInvalidTag, err)
+			}
+			rule.HalfOpen = b
+		case "allow":
+			for _, column := range strings.Split(v, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					rule.allow = append(rule.allow, column)
+				}
+			}
+		case "distinct":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return rule, fmt.Errorf("%w: distinct: %v", ErrInvalidTag, err)
+			}
+			rule.Distinct = b
+		case "map":
+			rule.Map = make(map[string]any)
+			for _, pair := range strings.Split(v, "|") {
+				from, to, ok := strings.Cut(pair, "=")
+				if !ok {
+					return rule, fmt.Errorf("%w: map: %q", ErrInvalidTag, pair)
+				}
+				rule.Map[strings.TrimSpace(from)] = tagValue(strings.TrimSpace(to))
+			}
+		case "default":
+			defaultValue = &v
+		case "when":
+			if !strings.Contains(v, "=") {
+				return rule, fmt.Errorf("%w: when: %q", ErrInvalidTag, v)
+			}
+			rule.When = v
+		case "validate":
+			for _, name := range strings.Split(v, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					rule.Validators = append(rule.Validators, name)
+				}
+			}
+		case "transform":
+			for _, name := range strings.Split(v, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					rule.Transform = append(rule.Transform, name)
+				}
+			}
+		case "not":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return rule, fmt.Errorf("%w: not: %v", ErrInvalidTag, err)
+			}
+			rule.Not = b
+		case "having":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return rule, fmt.Errorf("%w: having: %v", ErrInvalidTag, err)
+			}
+			rule.Having = b
+		case "wildcard":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return rule, fmt.Errorf("%w: wildcard: %v", ErrInvalidTag, err)
+			}
+			rule.Wildcard = b
+		default:
+			if strict {
+				return rule, fmt.Errorf("%w: unknown key %q", ErrInvalidTag, k)
+			}
+		}
+	}
+	if rule.Opt == Unscoped { // 不生成条件, 与分页等字段一样单独处理
+		rule.special = Unscoped
+	}
+	if defaultValue != nil { // 多值运算符的默认值以逗号分隔, 如 default:1,2
+		rule.Default = tagValue(*defaultValue)
+		if multiValue(rule.Opt) {
+			values := strings.Split(*defaultValue, ",")
+			defaults := make([]any, len(values))
+			for i, v := range values {
+				defaults[i] = tagValue(strings.TrimSpace(v))
+			}
+			rule.Default = defaults
 		}
+	}
+
+	return rule, nil
+}
 
-		queryStr := strings.Join(conditions, " OR ")
-		db.Where(queryStr, params...)
+// Validate checks the filter tags of the dest struct, reporting unknown tag keys, unknown operators
+// and fields missing both json names and gorm columns. It is meant to be called at startup or in tests.
+func Validate(dest any) error {
+	rt := reflect.TypeOf(dest)
+	if rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %v is not a struct", ErrInvalidValue, rt)
+	}
 
-		return db
+	var errs []error
+	for _, field := range reflect.VisibleFields(rt) {
+		rule, ok, err := FieldRule(field)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok && rule.When != "" {
+			if _, err := std.whenIndex(rt, rule.When); err != nil {
+				errs = append(errs, fmt.Errorf("field %s: %w", field.Name, err))
+			}
+		}
 	}
+
+	return errors.Join(errs...)
 }
 
-// parseRule parses a search rule and returns a condition string and a slice of parameters
-func parseRule(rule Rule, rfVal reflect.Value, conditions []string, params []interface{}) ([]string, []interface{}) {
-	if rule.Table != "" {
-		rule.Name = rule.Table + "." + rule.Name
+// FieldRule parses and checks the filter tag of a struct field like Validate, ok is false for fields
+// without a filter tag. The rule is named after the json name of the field, e.g. for code generators
+func FieldRule(field reflect.StructField) (rule Rule, ok bool, err error) {
+	filterTagStr := strings.Trim(field.Tag.Get(std.tagKey), " ;,")
+	if filterTagStr == "" || filterTagStr == "-" {
+		return rule, false, nil
 	}
-	if rule.Opt == "" {
-		rule.Opt = Eq
+
+	rule, err = parseTag(filterTagStr, true)
+	if err != nil {
+		return rule, true, fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	rule.Name = std.fieldName(field)
+	var errs []error
+	if rule.Name == "" {
+		errs = append(errs, fmt.Errorf("field %s: %w: missing json name or gorm column", field.Name, ErrInvalidTag))
 	}
+	if err := validateRule(rule); err != nil {
+		errs = append(errs, fmt.Errorf("field %s: %w", field.Name, err))
+	}
+	return rule, true, errors.Join(errs...)
+}
 
-	value := rfVal.Interface()
-	switch rule.Opt {
-	case Eq:
-		conditions = append(conditions, rule.Name+" = ?")
-		params = append(params, value)
-	case Like:
-		conditions = append(conditions, rule.Name+" like ?")
-		params = append(params, "%"+value.(string)+"%")
-	case Rlike:
-		conditions = append(conditions, rule.Name+" rlike ?")
-		params = append(params, value)
-	case GT:
-		conditions = append(conditions, rule.Name+" > ?")
-		params = append(params, value)
-	case LT:
-		conditions = append(conditions, rule.Name+" < ?")
-		params = append(params, value)
-	case GTE:
-		conditions = append(conditions, rule.Name+" >= ?")
-		params = append(params, value)
-	case LTE:
-		conditions = append(conditions, rule.Name+" <= ?")
-		params = append(params, value)
-	case In:
-		conditions = append(conditions, rule.Name+" in (?)")
-		params = append(params, value)
-	case DateRange:
-		dates := value.([]string)
-		if len(dates) != 2 {
-			panic("date_range rule requires two values")
+// ValidateRules checks the rules used by Search and MultiSearch, reporting missing names and unknown operators
+func ValidateRules(rules []Rule) error {
+	var errs []error
+	for i, rule := range rules {
+		if rule.Name == "" {
+			errs = append(errs, fmt.Errorf("rule %d: %w: missing name", i, ErrInvalidTag))
+		}
+		if err := validateRule(rule); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateColumns checks that the column and table names of an untrusted rule are plain identifiers
+func validateColumns(rule Rule) error {
+	if rule.Trusted {
+		return nil
+	}
+	idents := append([]string{rule.Name, rule.Column, rule.Table, rule.Alias, rule.ForeignKey, rule.References}, rule.Columns...)
+	for _, ident := range append(idents, rule.Cols...) {
+		if ident != "" && !isIdentifier(ident) {
+			return fmt.Errorf("%w: %q", ErrInvalidColumn, ident)
 		}
-		sTime := dates[0] + " 00:00:00"
-		eTime := dates[1] + " 23:59:59"
-		conditions = append(conditions, rule.Name+" between ? and ?")
-		params = append(params, sTime, eTime)
 	}
 
-	return conditions, params
+	return nil
+}
+
+// validateRule checks the column names, operator and logic of the rule
+func validateRule(rule Rule) error {
+	if err := validateColumns(rule); err != nil {
+		return err
+	}
+	if rule.Opt == Raw && rule.SQL == "" {
+		return fmt.Errorf("%w: raw rule requires sql", ErrInvalidTag)
+	}
+	if rule.Opt == ColCmp && len(rule.Cols) == 0 {
+		return fmt.Errorf("%w: col_cmp rule requires cols", ErrInvalidTag)
+	}
+	if rule.Opt != "" && !builtinOperators[rule.Opt] {
+		if _, ok := lookupOperator(rule.Opt); !ok {
+			return fmt.Errorf("%w: unknown operator %q", ErrInvalidTag, rule.Opt)
+		}
+	}
+	if rule.Rel != "" && !isIdentifier(rule.Rel) {
+		return fmt.Errorf("%w: rel %q", ErrInvalidTag, rule.Rel)
+	}
+	for _, kind := range rule.ZeroKinds {
+		if !zeroKinds[kind] {
+			return fmt.Errorf("%w: unknown zero kind %q", ErrInvalidTag, kind)
+		}
+	}
+	for _, column := range rule.allow {
+		if !isIdentifier(column) {
+			return fmt.Errorf("%w: %q", ErrInvalidColumn, column)
+		}
+	}
+	for _, opt := range rule.Ops {
+		if _, ok := operatorName(opt); !ok {
+			return fmt.Errorf("%w: unknown operator %q", ErrInvalidTag, opt)
+		}
+	}
+	for _, name := range rule.Transform {
+		if _, ok := lookupTransform(name); !ok {
+			return fmt.Errorf("%w: unknown transform %q", ErrInvalidTag, name)
+		}
+	}
+	for _, name := range rule.Validators {
+		if _, ok := lookupValidator(name); !ok {
+			return fmt.Errorf("%w: unknown validator %q", ErrInvalidTag, name)
+		}
+	}
+	switch rule.Type {
+	case "", TypeString, TypeInt, TypeUint, TypeFloat, TypeBool, TypeTime, TypeUUID:
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidTag, rule.Type)
+	}
+	if rule.TZ != "" {
+		if _, err := loadLocation(rule.TZ); err != nil {
+			return err
+		}
+	}
+	for _, logic := range []string{rule.Logic, rule.GroupLogic} {
+		if logic != "" && logic != LogicAnd && logic != LogicOr {
+			return fmt.Errorf("%w: unknown logic %q", ErrInvalidTag, logic)
+		}
+	}
+
+	return nil
+}
+
+// buildSearch builds the conditions from the rules and the dest struct
+func (e *Engine) buildSearch(db *gorm.DB, rules []Rule, dest any) (res result, err error) {
+	rv := reflect.ValueOf(dest)
+
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return res, nil
+	}
+	res.source = rv.Type().String()
+
+	if len(rules) == 0 {
+		return res, nil
+	}
+
+	// create a map of dest struct fields to their values
+	fields := reflect.VisibleFields(rv.Type())
+	destMap := make(map[string]reflect.Value, len(fields))
+	for _, field := range fields {
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			continue // 嵌入的结构体只使用其字段
+		}
+		fv, err := rv.FieldByIndexErr(field.Index)
+		if err != nil {
+			continue
+		}
+		if jsonField := e.fieldName(field); jsonField != "" {
+			destMap[jsonField] = fv
+		}
+	}
+
+	res.conditions = make([]condition, 0, len(rules))
+	for _, rule := range e.rules(rules) {
+		rfVal, ok := destMap[rule.Name]
+		if !ok {
+			res.skip("", rule.Name, SkipNoField)
+			continue
+		}
+		if !whenHolds(rule, func(name string) (reflect.Value, bool) {
+			v, ok := destMap[name]
+			return v, ok
+		}) {
+			res.skip("", rule.Name, SkipWhen)
+			continue
+		}
+		if rule.Opt == Unscoped {
+			rule.special = Unscoped
+			if err := res.special(rule, rfVal); err != nil {
+				return res, fmt.Errorf("field %s: %w", rule.Name, err)
+			}
+			continue
+		}
+
+		cond, ok, err := valueCondition(db, rule, rfVal)
+		if err != nil {
+			return res, err
+		}
+		if !ok {
+			res.skip("", rule.Name, skipReason(rule, rfVal))
+			continue
+		}
+		res.conditions = append(res.conditions, cond)
+	}
+
+	return res, nil
+}
+
+// buildMap builds the conditions from the rules and the values keyed by rule name
+func buildMap(db *gorm.DB, rules []Rule, values map[string]any) (res result, err error) {
+	res.source = "map"
+	res.conditions = make([]condition, 0, len(rules))
+	for _, rule := range rules {
+		v, ok := values[rule.Name]
+		if !ok && rule.Default == nil {
+			continue
+		}
+		if !whenHolds(rule, func(name string) (reflect.Value, bool) {
+			w, ok := values[name]
+			return reflect.ValueOf(w), ok && w != nil
+		}) {
+			continue
+		}
+		if v != nil { // 存在的键都使用, 如 "active": false
+			rule.UseZero = true
+		}
+		cond, ok, err := anyCondition(db, rule, v)
+		if err != nil {
+			return res, err
+		}
+		if ok {
+			res.conditions = append(res.conditions, cond)
+		}
+	}
+
+	return res, nil
+}
+
+// ruleMap returns the rules keyed by name, the first rule of a name wins
+func ruleMap(rules []Rule) map[string]Rule {
+	m := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		if _, ok := m[rule.Name]; !ok {
+			m[rule.Name] = rule
+		}
+	}
+	return m
+}
+
+// anyCondition is like valueCondition for a dynamic value, nil is skipped if UseZero is false
+func anyCondition(db *gorm.DB, rule Rule, v any) (condition, bool, error) {
+	rfVal := reflect.ValueOf(v)
+	if !rfVal.IsValid() { // null
+		if !rule.UseZero {
+			return defaultCondition(db, rule)
+		}
+		rfVal = reflect.ValueOf(&v).Elem()
+	}
+	return valueCondition(db, rule, rfVal)
+}
+
+// valueCondition returns the condition of rule for a field value, ok is false when the value is skipped:
+// unset Opt and null sql.Null* values, or zero values and empty slices if UseZero is false
+func valueCondition(db *gorm.DB, rule Rule, rfVal reflect.Value) (condition, bool, error) {
+	if sub, ok := subquery(rfVal); ok { // 子查询不变换、不映射
+		if sub == nil {
+			return defaultCondition(db, rule)
+		}
+		return parseRule(db, rule, rfVal)
+	}
+	v, valid, nullable := nullableValue(rfVal)
+	if nullable {
+		if !valid { // Opt 未设置或 sql.Null* 为 null 时忽略
+			return defaultCondition(db, rule)
+		}
+		rfVal = v
+	}
+	if hasTransforms(rule) { // 在零值判断前变换, 如 trim 后为空时忽略
+		var err error
+		if rfVal, err = transformValue(rule, rfVal); err != nil {
+			return condition{}, false, err
+		}
+		if !rfVal.IsValid() {
+			return defaultCondition(db, rule)
+		}
+	}
+	if emptyIn(rule, rfVal) { // 如权限系统传入的空 ID 列表, 忽略条件会返回所有行
+		return parseRule(db, rule, rfVal)
+	}
+	if !nullable {
+		// Skip zero values and empty slices if UseZero is false
+		emptySlice := rfVal.Kind() == reflect.Slice && rfVal.Len() == 0 // 兼容空切片
+		if (zeroValue(rfVal) || emptySlice) && !usesZero(rule, rfVal) {
+			return defaultCondition(db, rule)
+		}
+	}
+	if err := validateValue(rule, rfVal); err != nil { // 校验映射前的输入值
+		return condition{}, false, err
+	}
+	if rule.Map != nil { // 在零值判断后映射, 映射后的零值如 inactive=0 仍然使用
+		var err error
+		if rfVal, err = mapValue(rule, rfVal); err != nil {
+			return condition{}, false, err
+		}
+	}
+
+	return parseRule(db, rule, rfVal)
+}
+
+var (
+	multiSearchTokens    bool // MultiSearch 是否分别匹配关键词的每个词
+	multiSearchRelevance bool // MultiSearch 是否按匹配程度排序
+)
+
+// SetMultiSearchTokens makes MultiSearch match every word of the keyword against any rule
+func SetMultiSearchTokens(tokens bool) {
+	multiSearchTokens = tokens
+}
+
+// SetMultiSearchRelevance makes MultiSearch order the rows by exact, then prefix, then other matches
+// of the keyword, replacing the other orders of the query
+func SetMultiSearchRelevance(relevance bool) {
+	multiSearchRelevance = relevance
+}
+
+// defaultCondition returns the condition of rule for its default value, ok is false without a default.
+// The default is mapped like field values
+func defaultCondition(db *gorm.DB, rule Rule) (condition, bool, error) {
+	if rule.Default == nil {
+		return condition{}, false, nil
+	}
+	rfVal := reflect.ValueOf(rule.Default)
+	if rule.Map != nil {
+		var err error
+		if rfVal, err = mapValue(rule, rfVal); err != nil {
+			return condition{}, false, err
+		}
+	}
+	return parseRule(db, rule, rfVal)
+}
+
+// buildMultiSearch builds the conditions matching the dest string against every rule with OR
+func (e *Engine) buildMultiSearch(db *gorm.DB, rules []Rule, dest string) (res result, err error) {
+	res.source = "multi_search"
+	dest = strings.TrimSpace(dest)
+	if dest == "" {
+		return res, nil
+	}
+	if len(rules) == 0 {
+		return res, nil
+	}
+	rules = e.rules(rules)
+
+	if multiSearchTokens {
+		for _, token := range strings.Fields(dest) {
+			exprs := make([]clause.Expression, 0, len(rules))
+			for _, rule := range rules {
+				rfVal, ok, err := keywordValue(rule, token)
+				if err != nil {
+					return res, err
+				}
+				if !ok {
+					continue
+				}
+				cond, ok, err := parseRule(db, rule, rfVal)
+				if err != nil {
+					return res, err
+				}
+				if ok {
+					exprs = append(exprs, cond.expr)
+				}
+			}
+			if expr, ok, _ := joinExprs(exprs, LogicOr); ok { // 每个词匹配任一规则, 词之间为 AND
+				res.conditions = append(res.conditions, condition{expr: expr})
+			}
+		}
+		res.orderBy = relevanceOrder(db, rules, dest, len(res.conditions) > 0)
+		return res, nil
+	}
+
+	for _, rule := range rules {
+		rfVal, ok, err := keywordValue(rule, dest)
+		if err != nil {
+			return res, err
+		}
+		if !ok {
+			continue
+		}
+		cond, ok, err := parseRule(db, rule, rfVal)
+		if err != nil {
+			return res, err
+		}
+		if ok {
+			cond.logic = LogicOr
+			cond.group, cond.having = "", false
+			res.conditions = append(res.conditions, cond)
+		}
+	}
+	res.orderBy = relevanceOrder(db, rules, dest, len(res.conditions) > 0)
+
+	return res, nil
+}
+
+// relevanceOrder returns the MultiSearch order by how well the text columns of the rules match the keyword,
+// nil if relevance ordering is off or nothing matched. The rule columns are checked by parseRule before
+func relevanceOrder(db *gorm.DB, rules []Rule, keyword string, matched bool) clause.Expression {
+	if !multiSearchRelevance || !matched {
+		return nil
+	}
+	var exact, prefix []clause.Expression
+	for _, rule := range rules {
+		switch rule.Opt {
+		case "", Eq, Like, StartsWith, EndsWith, ILike:
+		default:
+			continue
+		}
+		if rule.Type != "" && rule.Type != TypeString {
+			continue
+		}
+		columns := rule.Columns
+		if len(columns) == 0 {
+			columns = []string{rule.Name}
+			if rule.Column != "" {
+				columns[0] = rule.Column
+			}
+		}
+		for _, name := range columns {
+			col := clause.Column{Table: qualifier(db, rule), Name: name, Raw: rule.Trusted}
+			exact = append(exact, clause.Eq{Column: col, Value: keyword})
+			prefix = append(prefix, like(col, likeReplacer.Replace(keyword)+"%", true))
+		}
+	}
+	if len(exact) == 0 {
+		return nil
+	}
+
+	expr := clause.Expr{SQL: "case when ? then 0 when ? then 1 else 2 end", Vars: []interface{}{clause.Or(exact...), clause.Or(prefix...)}}
+	if tieBreaker != "" {
+		expr.SQL += ", ?"
+		expr.Vars = append(expr.Vars, clause.Column{Name: tieBreaker})
+	}
+	return expr
+}
+
+// keywordValue transforms a MultiSearch keyword and converts it to the type of the rule, ok is false if it
+// cannot be converted, fails validation or a transform drops it. Keywords of like and regexp operators stay
+// strings once they convert
+func keywordValue(rule Rule, keyword string) (v reflect.Value, ok bool, err error) {
+	if hasTransforms(rule) {
+		if v, err = transformValue(rule, reflect.ValueOf(keyword)); err != nil || !v.IsValid() {
+			return v, false, err
+		}
+		if v.Kind() != reflect.String { // 变换为其他类型时直接使用
+			return v, true, nil
+		}
+		keyword = v.String()
+	}
+	if rule.Map != nil { // 无法映射的关键词不匹配该规则
+		value, ok := rule.Map[keyword]
+		return reflect.ValueOf(value), ok && value != nil, nil
+	}
+
+	value, err := typedValue(rule, keyword)
+	if err != nil {
+		return v, false, nil
+	}
+	switch rule.Opt {
+	case Like, NotLike, StartsWith, EndsWith, ILike, Rlike, Regexp:
+		value = keyword
+	}
+	if validateValue(rule, reflect.ValueOf(value)) != nil { // 校验失败的关键词不匹配该规则
+		return v, false, nil
+	}
+	return reflect.ValueOf(value), true, nil
+}
+
+// typedValue converts s to the column Type of rule, s itself for strings and rules without a Type
+func typedValue(rule Rule, s string) (any, error) {
+	switch rule.Type {
+	case TypeInt:
+		return strconv.ParseInt(s, 10, 64)
+	case TypeUint:
+		return strconv.ParseUint(s, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(s, 64)
+	case TypeBool:
+		return strconv.ParseBool(s)
+	case TypeTime:
+		return parseTime(s, rule.Layout, rule.TZ)
+	case TypeUUID:
+		return s, validUUID(s)
+	}
+	return s, nil
+}
+
+// parseRule parses a search rule and returns the generated condition, ok is false for unknown operators
+func parseRule(db *gorm.DB, rule Rule, rfVal reflect.Value) (cond condition, ok bool, err error) {
+	cond.name = rule.Name
+	cond.logic = rule.Logic
+	cond.group = rule.Group
+	cond.groupLogic = rule.GroupLogic
+	cond.not = rule.Not
+	cond.having = rule.Having
+	if rule.Name == "" {
+		return cond, false, fmt.Errorf("%w: empty name", ErrInvalidColumn)
+	}
+	if err := validateColumns(rule); err != nil {
+		return cond, false, err
+	}
+	if rule.Rel != "" { // 关联表的列, 使用子查询
+		return relCondition(db, rule, rfVal)
+	}
+	if len(rule.Columns) > 0 { // 任一列匹配即可
+		exprs := make([]clause.Expression, 0, len(rule.Columns))
+		columns := make([]string, 0, len(rule.Columns))
+		for _, column := range rule.Columns {
+			r := rule
+			r.Column, r.Columns = column, nil
+			c, ok, err := parseRule(db, r, rfVal)
+			if err != nil || !ok {
+				return cond, ok, err
+			}
+			exprs = append(exprs, c.expr)
+			columns = append(columns, c.column)
+			cond.opt = c.opt
+		}
+		if len(exprs) == 1 {
+			cond.expr = exprs[0]
+		} else {
+			cond.expr = clause.Or(exprs...)
+		}
+		cond.column = strings.Join(columns, ",")
+		return cond, true, nil
+	}
+	if rule.Column != "" {
+		rule.Name = rule.Column
+	}
+	col := clause.Column{Table: qualifier(db, rule), Name: rule.Name, Raw: rule.Trusted} // 按数据库方言转义
+	if rule.Opt == "" {
+		rule.Opt = Eq
+	}
+	cond.column, cond.opt = rule.Name, rule.Opt
+	if col.Table != "" {
+		cond.column = col.Table + "." + rule.Name
+	}
+
+	if sub, ok := subquery(rfVal); ok {
+		cond.expr, err = subqueryCondition(rule, col, sub)
+		return cond, err == nil, err
+	}
+
+	for rfVal.Kind() == reflect.Ptr && !rfVal.IsNil() { // *string, *time.Time 等使用指向的值
+		rfVal = rfVal.Elem()
+	}
+	if rfVal, err = uuidValue(db, rule, rfVal); err != nil {
+		return cond, false, err
+	}
+	value := rfVal.Interface()
+	if rule.Layout != "" {
+		switch rule.Opt {
+		case Eq, Neq, "neq", GT, LT, GTE, LTE:
+			if value, err = layoutValue(value, rule.Layout); err != nil {
+				return cond, false, err
+			}
+		}
+	}
+	str, isStr := value.(string)
+	var escaped bool
+	switch rule.Opt {
+	case Like, NotLike, StartsWith, EndsWith, ILike:
+		if !isStr {
+			return cond, false, fmt.Errorf("%w: %s rule requires a string value", ErrInvalidValue, rule.Opt)
+		}
+		if !rule.Wildcard { // 转义用户输入中的通配符
+			str = likeReplacer.Replace(str)
+			escaped = true
+		}
+	}
+
+	switch rule.Opt {
+	case Eq:
+		cond.expr = clause.Eq{Column: col, Value: value}
+	case Neq, "neq":
+		cond.expr = clause.Neq{Column: col, Value: value}
+	case Like:
+		cond.expr = like(col, "%"+str+"%", escaped)
+	case NotLike:
+		sql := "? not like ?"
+		if escaped {
+			sql = "? not like ?" + likeEscapeClause
+		}
+		cond.expr = clause.Expr{SQL: sql, Vars: []interface{}{col, "%" + str + "%"}}
+	case StartsWith:
+		cond.expr = like(col, str+"%", escaped)
+	case EndsWith:
+		cond.expr = like(col, "%"+str, escaped)
+	case ILike:
+		sql := "lower(?) like lower(?)"
+		if dialect(db) == "postgres" {
+			sql = "? ilike ?"
+		}
+		if escaped {
+			sql += likeEscapeClause
+		}
+		cond.expr = clause.Expr{SQL: sql, Vars: []interface{}{col, "%" + str + "%"}}
+	case Rlike:
+		cond.expr = clause.Expr{SQL: "? rlike ?", Vars: []interface{}{col, value}}
+	case Regexp:
+		sql := "? regexp ?"
+		if dialect(db) == "postgres" {
+			sql = "? ~ ?"
+		}
+		cond.expr = clause.Expr{SQL: sql, Vars: []interface{}{col, value}}
+	case GT:
+		cond.expr = clause.Gt{Column: col, Value: value}
+	case LT:
+		cond.expr = clause.Lt{Column: col, Value: value}
+	case GTE:
+		cond.expr = clause.Gte{Column: col, Value: value}
+	case LTE:
+		cond.expr = clause.Lte{Column: col, Value: value}
+	case In:
+		values := sliceValues(rfVal)
+		if len(values) == 0 { // 空列表不匹配任何行
+			cond.expr = clause.Expr{SQL: "1 = 0"}
+		} else if cond.expr, err = in(col, values, false); err != nil {
+			return cond, false, err
+		}
+	case NotIn:
+		values := sliceValues(rfVal)
+		if len(values) == 0 { // 空列表不排除任何行, 不能生成 IS NOT NULL
+			return cond, false, nil
+		}
+		if cond.expr, err = in(col, values, true); err != nil {
+			return cond, false, err
+		}
+	case LastDays:
+		n, err := intValue(rfVal, rule.Opt)
+		if err != nil {
+			return cond, false, err
+		}
+		cond.expr = clause.Gte{Column: col, Value: now().Add(-time.Duration(n) * 24 * time.Hour)}
+	case LastHours:
+		n, err := intValue(rfVal, rule.Opt)
+		if err != nil {
+			return cond, false, err
+		}
+		cond.expr = clause.Gte{Column: col, Value: now().Add(-time.Duration(n) * time.Hour)}
+	case IsNull:
+		cond.expr = clause.Eq{Column: col, Value: nil}
+	case NotNull:
+		cond.expr = clause.Neq{Column: col, Value: nil}
+	case Between:
+		min, max, err := rangeBounds(rfVal, rule.Opt, rule.Layout)
+		if err != nil {
+			return cond, false, err
+		}
+		if cond.expr = boundedRange(col, min, max); cond.expr == nil {
+			return cond, false, nil
+		}
+	case JSONContains:
+		v, err := jsonValue(value)
+		if err != nil {
+			return cond, false, err
+		}
+		sql := "json_contains(?, ?)"
+		if dialect(db) == "postgres" {
+			sql = "? @> ?"
+		}
+		cond.expr = clause.Expr{SQL: sql, Vars: []interface{}{col, v}}
+	case ArrayContains:
+		cond.expr = clause.Expr{SQL: "? @> ?", Vars: []interface{}{col, arrayLiteral(rfVal)}}
+	case ArrayAny:
+		cond.expr = clause.Expr{SQL: "? = any(?)", Vars: []interface{}{value, col}}
+	case Exists:
+		if rule.Table == "" || rule.ForeignKey == "" || rule.References == "" {
+			return cond, false, fmt.Errorf("%w: exists rule requires table, fk and ref", ErrInvalidTag)
+		}
+		ref := clause.Column{Name: rule.References, Raw: rule.Trusted}
+		if strings.Contains(rule.References, ".") {
+			ref.Name = prefixTable(db, rule.References)
+		} else {
+			ref.Table = clause.CurrentTable // 外层查询的表, 否则会解析为子查询表的列
+		}
+		var match clause.Expression = clause.Eq{Column: col, Value: value}
+		if rfVal.Kind() == reflect.Slice {
+			if match, err = in(col, sliceValues(rfVal), false); err != nil {
+				return cond, false, err
+			}
+		}
+		cond.expr = clause.Expr{
+			SQL: "exists (select 1 from ? where ? = ? and ?)",
+			Vars: []interface{}{
+				clause.Table{Name: prefixTable(db, rule.Table), Alias: rule.Alias, Raw: rule.Trusted},
+				clause.Column{Table: col.Table, Name: rule.ForeignKey, Raw: rule.Trusted},
+				ref, match,
+			},
+		}
+	case ColCmp:
+		cmp := rule.Cmp
+		if cmp == "" {
+			cmp = Eq
+		}
+		if !colCmpOperators[cmp] {
+			return cond, false, fmt.Errorf("%w: unsupported col_cmp operator %q", ErrInvalidTag, cmp)
+		}
+		if len(rule.Cols) == 0 { // 列名来自请求, 只能比较服务端允许的列
+			return cond, false, fmt.Errorf("%w: col_cmp rule requires cols", ErrInvalidTag)
+		}
+		if !contains(rule.Cols, str) {
+			return cond, false, fmt.Errorf("%w: col_cmp column %q is not allowed", ErrInvalidValue, str)
+		}
+		cond.expr = clause.Expr{SQL: "? " + cmp + " ?", Vars: []interface{}{col, clause.Column{Name: str}}}
+	case DateRange:
+		halfOpen := rule.HalfOpen || dateRangeHalfOpen
+		sTime, eTime, err := dateRange(rfVal, rule.Layout, rule.TZ, halfOpen)
+		if err != nil {
+			return cond, false, err
+		}
+		switch {
+		case sTime == nil && eTime == nil:
+			return cond, false, nil
+		case sTime == nil && halfOpen:
+			cond.expr = clause.Lt{Column: col, Value: eTime}
+		case sTime == nil:
+			cond.expr = clause.Lte{Column: col, Value: eTime}
+		case eTime == nil:
+			cond.expr = clause.Gte{Column: col, Value: sTime}
+		case halfOpen:
+			cond.expr = clause.And(clause.Gte{Column: col, Value: sTime}, clause.Lt{Column: col, Value: eTime})
+		default:
+			cond.expr = between(col, sTime, eTime)
+		}
+	case DatetimeRange:
+		sTime, eTime, err := rangeBounds(rfVal, rule.Opt, rule.Layout)
+		if err != nil {
+			return cond, false, err
+		}
+		if cond.expr = boundedRange(col, sTime, eTime); cond.expr == nil {
+			return cond, false, nil
+		}
+	case Raw:
+		if rule.SQL == "" {
+			return cond, false, fmt.Errorf("%w: raw rule requires sql", ErrInvalidTag)
+		}
+		cond.expr = rawExpr(rule.SQL, value)
+	case Bool3:
+		b, set, err := bool3Value(rfVal)
+		if err != nil || !set {
+			return cond, false, err
+		}
+		cond.expr = clause.Eq{Column: col, Value: b}
+	case Unscoped: // 仅结构体字段支持, 不生成条件
+		return cond, false, nil
+	default:
+		fn, ok := lookupOperator(rule.Opt)
+		if !ok {
+			return cond, false, nil
+		}
+		if col.Table != "" {
+			rule.Name = col.Table + "." + rule.Name
+		}
+		sql, params := fn(rule, rfVal)
+		cond.expr = clause.Expr{SQL: sql, Vars: params}
+	}
+
+	return cond, true, nil
+}
+
+// rawExpr returns the expression of a raw rule, value fills every placeholder of sql
+func rawExpr(sql string, value interface{}) clause.Expression {
+	vars := make([]interface{}, strings.Count(sql, "?"))
+	for i := range vars {
+		vars[i] = value
+	}
+	return clause.Expr{SQL: sql, Vars: vars}
+}
+
+// splitHaving splits the conditions into those of WHERE and those of HAVING
+func splitHaving(conditions []condition) (where, having []condition) {
+	for i, cond := range conditions {
+		if !cond.having {
+			continue
+		}
+		where = append(where, conditions[:i]...)
+		for _, cond := range conditions[i:] {
+			if cond.having {
+				having = append(having, cond)
+			} else {
+				where = append(where, cond)
+			}
+		}
+		return where, having
+	}
+	return conditions, nil // 没有 HAVING 条件时无需复制
+}
+
+// joinConditions joins conditions and groups with AND, those with or logic are OR'd together as one group
+func joinConditions(conditions []condition) clause.Expression {
+	return combineConditions(groupConditions(conditions))
+}
+
+// addWhere adds expr to the WHERE clause of db with AND. Existing conditions chained with Or, such as
+// db.Where("a = 1").Or("b = 2"), are grouped first so that expr restricts all of them instead of the last one
+func addWhere(db *gorm.DB, expr clause.Expression) {
+	if expr == nil {
+		return
+	}
+	if c, ok := db.Statement.Clauses["WHERE"]; ok {
+		if w, ok := c.Expression.(clause.Where); ok && orChained(w.Exprs) {
+			c.Expression = clause.Where{Exprs: []clause.Expression{clause.AndConditions{Exprs: w.Exprs}}}
+			db.Statement.Clauses["WHERE"] = c
+		}
+	}
+	db.Where(clause.And(expr)) // Or 条件包裹为 And, 避免与已有条件以 OR 连接
+}
+
+// orChained reports whether the WHERE expressions contain a condition added by db.Or
+func orChained(exprs []clause.Expression) bool {
+	for _, expr := range exprs {
+		if or, ok := expr.(clause.OrConditions); ok && len(or.Exprs) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// joinExprs joins the expressions with the logic, ok is false if there are none
+func joinExprs(exprs []clause.Expression, logic string) (clause.Expression, bool, error) {
+	switch {
+	case len(exprs) == 0:
+		return nil, false, nil
+	case len(exprs) == 1:
+		return exprs[0], true, nil
+	case logic == LogicOr:
+		return clause.Or(exprs...), true, nil
+	default:
+		return clause.And(exprs...), true, nil
+	}
+}
+
+// groupConditions collapses the conditions of each group into one condition,
+// placed where the first member of the group appears, and negates the conditions and groups with not
+func groupConditions(conditions []condition) []condition {
+	grouped := false
+	for _, cond := range conditions {
+		if cond.group != "" || cond.not {
+			grouped = true
+			break
+		}
+	}
+	if !grouped { // 没有分组和取反时无需复制
+		return conditions
+	}
+
+	result := make([]condition, 0, len(conditions))
+	groupIdx := make(map[string]int)
+	members := make(map[string][]condition)
+	for _, cond := range conditions {
+		if cond.group == "" {
+			if cond.not {
+				cond.expr = not(cond.expr)
+			}
+			result = append(result, cond)
+			continue
+		}
+
+		idx, ok := groupIdx[cond.group]
+		if !ok {
+			idx = len(result)
+			groupIdx[cond.group] = idx
+			result = append(result, condition{})
+		}
+		if cond.groupLogic != "" {
+			result[idx].logic = cond.groupLogic
+		}
+		if cond.not { // 任一成员设置 not 即对整个分组取反
+			result[idx].not = true
+		}
+		members[cond.group] = append(members[cond.group], cond)
+	}
+
+	for group, idx := range groupIdx {
+		result[idx].expr = combineConditions(members[group])
+		if result[idx].not {
+			result[idx].expr = not(result[idx].expr)
+		}
+	}
+
+	return result
+}
+
+// not negates expr as a whole, unlike clause.Not which negates each member of an AND separately
+func not(expr clause.Expression) clause.Expression {
+	switch e := expr.(type) {
+	case clause.AndConditions: // 多个条件时自带括号
+		if len(e.Exprs) > 1 {
+			return clause.Expr{SQL: "NOT ?", Vars: []interface{}{expr}}
+		}
+	case clause.OrConditions:
+		if len(e.Exprs) > 1 {
+			return clause.Expr{SQL: "NOT ?", Vars: []interface{}{expr}}
+		}
+	}
+	return clause.Expr{SQL: "NOT (?)", Vars: []interface{}{expr}}
+}
+
+// combineConditions joins conditions with AND, conditions with or logic are OR'd together as one group
+func combineConditions(conditions []condition) clause.Expression {
+	ands := make([]clause.Expression, 0, len(conditions)+1) // 多留一个位置给 or 组
+	var ors []clause.Expression
+	for _, cond := range conditions {
+		if cond.logic == LogicOr {
+			ors = append(ors, cond.expr)
+		} else {
+			ands = append(ands, cond.expr)
+		}
+	}
+
+	switch {
+	case len(ors) == 1:
+		ands = append(ands, ors[0])
+	case len(ors) > 1 && len(ands) == 0:
+		return clause.Or(ors...)
+	case len(ors) > 1:
+		ands = append(ands, clause.Or(ors...))
+	}
+
+	return clause.And(ands...)
+}
+
+// like returns a like expression, with an escape clause if escaped
+func like(col clause.Column, pattern string, escaped bool) clause.Expression {
+	if !escaped {
+		return clause.Like{Column: col, Value: pattern}
+	}
+	return clause.Expr{SQL: "? like ?" + likeEscapeClause, Vars: []interface{}{col, pattern}}
+}
+
+var (
+	maxInSize int  // in 列表的最大长度, 为 0 时不限制
+	chunkIn   bool // 超过最大长度时是否拆分为多个 in
+	emptyNone bool // in 规则的空切片是否不匹配任何行
+)
+
+// SetEmptyNone makes in rules match no rows for empty but non-nil slices, like the empty_none tag option
+func SetEmptyNone(none bool) {
+	emptyNone = none
+}
+
+// emptyIn reports whether rfVal is an empty but non-nil slice of an in rule matching no rows
+func emptyIn(rule Rule, rfVal reflect.Value) bool {
+	if rule.Opt != In || !rule.EmptyNone && !emptyNone {
+		return false
+	}
+	for rfVal.Kind() == reflect.Ptr && !rfVal.IsNil() {
+		rfVal = rfVal.Elem()
+	}
+	return rfVal.Kind() == reflect.Slice && !rfVal.IsNil() && rfVal.Len() == 0
+}
+
+// SetMaxInSize limits in and not_in rules to max values, longer lists are invalid values or split into
+// chunks if chunk is true. 0 disables the limit
+func SetMaxInSize(max int, chunk bool) {
+	maxInSize, chunkIn = max, chunk
+}
+
+// in returns an in or not in expression, splitting the values into chunks of the max in size if enabled
+func in(col clause.Column, values []interface{}, not bool) (clause.Expression, error) {
+	if maxInSize <= 0 || len(values) <= maxInSize {
+		if not {
+			return clause.Not(clause.IN{Column: col, Values: values}), nil
+		}
+		return clause.IN{Column: col, Values: values}, nil
+	}
+	if !chunkIn {
+		return nil, fmt.Errorf("%w: %d values exceed the in limit of %d", ErrInvalidValue, len(values), maxInSize)
+	}
+
+	exprs := make([]clause.Expression, 0, (len(values)+maxInSize-1)/maxInSize)
+	for start := 0; start < len(values); start += maxInSize {
+		end := start + maxInSize
+		if end > len(values) {
+			end = len(values)
+		}
+		var expr clause.Expression = clause.IN{Column: col, Values: values[start:end]}
+		if not {
+			expr = clause.Not(expr)
+		}
+		exprs = append(exprs, expr)
+	}
+	if not {
+		return clause.And(exprs...), nil
+	}
+	return clause.Or(exprs...), nil
+}
+
+// between returns a between expression
+func between(col clause.Column, min, max interface{}) clause.Expression {
+	return clause.Expr{SQL: "? between ? and ?", Vars: []interface{}{col, min, max}}
+}
+
+// boundedRange returns the condition of a range, col >= min or col <= max if the other bound is empty
+// and nil if both are
+func boundedRange(col clause.Column, min, max interface{}) clause.Expression {
+	switch minEmpty, maxEmpty := emptyBound(min), emptyBound(max); {
+	case minEmpty && maxEmpty:
+		return nil
+	case minEmpty:
+		return clause.Lte{Column: col, Value: max}
+	case maxEmpty:
+		return clause.Gte{Column: col, Value: min}
+	}
+	return between(col, min, max)
+}
+
+// arrayElemReplacer escapes the elements of postgres array literals
+var arrayElemReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// arrayLiteral formats a slice as a postgres array literal like {"a","b"},
+// binding the slice itself would be expanded into a value list
+func arrayLiteral(rfVal reflect.Value) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, v := range sliceValues(rfVal) {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(`"` + arrayElemReplacer.Replace(fmt.Sprint(v)) + `"`)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// sliceValues returns the elements of a slice or array value, other values are returned as a single element
+func sliceValues(rfVal reflect.Value) []interface{} {
+	if rfVal.Kind() != reflect.Slice && rfVal.Kind() != reflect.Array {
+		return []interface{}{rfVal.Interface()}
+	}
+	values := make([]interface{}, rfVal.Len())
+	for i := range values {
+		values[i] = rfVal.Index(i).Interface()
+	}
+	return values
+}
+
+// likeEscape is the escape character of like patterns
+const likeEscape = "!"
+
+// likeEscapeClause is the escape clause of like patterns
+const likeEscapeClause = " escape '" + likeEscape + "'"
+
+// likeReplacer escapes the wildcards of like patterns
+var likeReplacer = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// colCmpOperators are the comparison operators allowed by col_cmp rules
+var colCmpOperators = map[string]bool{Eq: true, Neq: true, GT: true, LT: true, GTE: true, LTE: true}
+
+// isIdentifier reports whether s is a column name made up of letters, digits, underscores and dots
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r != '_' && r != '.' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// rangeBounds returns the bounds of a Range or a two-element slice or array, converted by layoutValue if layout is set
+func rangeBounds(rfVal reflect.Value, opt, layout string) (min interface{}, max interface{}, err error) {
+	if r, ok := rfVal.Interface().(Range); ok {
+		min, max = r.Min, r.Max
+	} else if (rfVal.Kind() == reflect.Slice || rfVal.Kind() == reflect.Array) && rfVal.Len() == 2 {
+		min, max = rfVal.Index(0).Interface(), rfVal.Index(1).Interface()
+	} else {
+		return nil, nil, fmt.Errorf("%w: %s rule requires two values", ErrInvalidValue, opt)
+	}
+
+	if layout != "" {
+		if min, err = layoutValue(min, layout); err != nil {
+			return nil, nil, err
+		}
+		if max, err = layoutValue(max, layout); err != nil {
+			return nil, nil, err
+		}
+	}
+	return min, max, nil
+}
+
+// dateRange returns the bounds of a date_range value covering whole days. Without layout and time zone
+// strings are completed with the time of day, otherwise the days are taken in the time zone tz
+// (SetDateLocation by default) and their bounds are converted to the database time zone.
+// The upper bound is the midnight after the last day if halfOpen is true, empty bounds are returned as nil
+func dateRange(rfVal reflect.Value, layout, tz string, halfOpen bool) (interface{}, interface{}, error) {
+	start, end, err := rangeBounds(rfVal, DateRange, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if emptyBound(start) {
+		start = nil
+	}
+	if emptyBound(end) {
+		end = nil
+	}
+
+	loc := dateLocation
+	if tz != "" {
+		if loc, err = loadLocation(tz); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, sStr := start.(string)
+	_, eStr := end.(string)
+	if layout == "" && loc == nil && (sStr || start == nil) && (eStr || end == nil) {
+		if start != nil {
+			start = start.(string) + " 00:00:00"
+		}
+		if end != nil && halfOpen {
+			eTime, err := timeValue(end, time.DateOnly, time.Local)
+			if err != nil {
+				return nil, nil, err
+			}
+			end = eTime.AddDate(0, 0, 1).Format(time.DateOnly) + " 00:00:00"
+		} else if end != nil {
+			end = end.(string) + " 23:59:59"
+		}
+		return start, end, nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if start != nil {
+		sTime, err := timeValue(start, layout, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		start = startOfDay(sTime.In(loc)).In(dbLocation)
+	}
+	if end != nil {
+		eTime, err := timeValue(end, layout, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		eTime = startOfDay(eTime.In(loc)).AddDate(0, 0, 1)
+		if !halfOpen {
+			eTime = eTime.Add(-time.Nanosecond)
+		}
+		end = eTime.In(dbLocation)
+	}
+	return start, end, nil
+}
+
+// emptyBound reports whether a range bound is missing, i.e. nil, a blank string or a zero time
+func emptyBound(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case time.Time:
+		return v.IsZero()
+	}
+	return false
+}
+
+// layoutValue parses strings into times and formats times into strings with layout, other values are returned as is
+func layoutValue(value interface{}, layout string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return timeValue(v, layout, time.Local)
+	case time.Time:
+		return v.Format(layout), nil
+	}
+	return value, nil
+}
+
+// timeValue returns the time of a time.Time or a string parsed with layout in loc
+func timeValue(value interface{}, layout string, loc *time.Location) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if layout == "" {
+			layout = time.DateOnly
+		}
+		t, err := time.ParseInLocation(layout, strings.TrimSpace(v), loc)
+		if err != nil {
+			return t, fmt.Errorf("%w: %v", ErrInvalidValue, err)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%w: %T is not a time", ErrInvalidValue, value)
+}
+
+var (
+	dateLocation *time.Location // date_range 日期所在时区, 为空时字符串日期直接拼接时分秒
+	dbLocation   = time.UTC     // date_range 边界绑定前转换到的数据库时区
+	locations    sync.Map       // 时区名 -> *time.Location
+
+	dateRangeHalfOpen bool // date_range 是否使用左闭右开区间
+)
+
+// SetDateRangeHalfOpen makes date_range rules end before the next midnight, like the half_open tag option
+func SetDateRangeHalfOpen(halfOpen bool) {
+	dateRangeHalfOpen = halfOpen
+}
+
+// SetDateLocation sets the time zone of date_range days and of times parsed without a zone,
+// the tz tag option overrides it
+func SetDateLocation(loc *time.Location) {
+	dateLocation = loc
+}
+
+// SetDBLocation sets the time zone date_range bounds are converted to before binding, UTC by default
+func SetDBLocation(loc *time.Location) {
+	dbLocation = loc
+}
+
+// timeLocation returns the location of the time zone tz, of SetDateLocation without tz or the local time zone
+func timeLocation(tz string) (*time.Location, error) {
+	if tz != "" {
+		return loadLocation(tz)
+	}
+	if dateLocation != nil {
+		return dateLocation, nil
+	}
+	return time.Local, nil
+}
+
+// loadLocation returns the location of the time zone name, caching loaded locations
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTag, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// startOfDay returns midnight of the day of t
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// intValue returns the integer value of rfVal, numeric strings are parsed
+func intValue(rfVal reflect.Value, opt string) (int64, error) {
+	switch rfVal.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rfVal.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rfVal.Uint()), nil
+	case reflect.String:
+		if n, err := strconv.ParseInt(strings.TrimSpace(rfVal.String()), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s rule requires an integer value", ErrInvalidValue, opt)
+}
+
+// bool3Value returns the boolean of a bool3 rule: a bool, or a string parsed by strconv.ParseBool such as
+// true, false, 1 or 0. Blank strings are not set, so a select of all, true and false needs no other value
+func bool3Value(rfVal reflect.Value) (b, set bool, err error) {
+	switch rfVal.Kind() {
+	case reflect.Bool:
+		return rfVal.Bool(), true, nil
+	case reflect.String:
+		s := strings.TrimSpace(rfVal.String())
+		if s == "" {
+			return false, false, nil
+		}
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, true, nil
+		}
+	}
+	return false, false, fmt.Errorf("%w: bool3 rule requires true or false", ErrInvalidValue)
+}
+
+// jsonValue marshals value to a JSON string, json.RawMessage is used as is
+func jsonValue(value interface{}) (string, error) {
+	if raw, ok := value.(json.RawMessage); ok {
+		return string(raw), nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return string(b), nil
+}
+
+// zeroKinds are the value kinds of use_zero, the column types of rules
+var zeroKinds = map[string]bool{TypeString: true, TypeInt: true, TypeUint: true, TypeFloat: true, TypeBool: true, TypeTime: true}
+
+// usesZero reports whether rule uses the zero value rfVal, all zero values with UseZero or those of its ZeroKinds.
+// Decimals are of the float kind, nil pointers are not of any kind
+func usesZero(rule Rule, rfVal reflect.Value) bool {
+	if rule.UseZero {
+		return true
+	}
+	if len(rule.ZeroKinds) == 0 {
+		return false
+	}
+	var kind string
+	switch rfVal.Kind() {
+	case reflect.String:
+		kind = TypeString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		kind = TypeInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		kind = TypeUint
+	case reflect.Float32, reflect.Float64:
+		kind = TypeFloat
+	case reflect.Bool:
+		kind = TypeBool
+	case reflect.Struct:
+		if rfVal.Type() == reflect.TypeOf(time.Time{}) {
+			kind = TypeTime
+		} else if _, ok := decimalValue(rfVal); ok { // decimal.Decimal 等视为浮点数
+			kind = TypeFloat
+		}
+	}
+	for _, k := range rule.ZeroKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// nullableValue unwraps Opt and sql.Null* like values, which have a Valid field and implement driver.Valuer,
+// ok is false for other values and valid reports whether the value is set and not null
+func nullableValue(rfVal reflect.Value) (value reflect.Value, valid, ok bool) {
+	if o, isOpt := rfVal.Interface().(optional); isOpt {
+		v, set := o.optionalValue()
+		return v, set, true
+	}
+	if rfVal.Kind() != reflect.Struct {
+		return rfVal, false, false
+	}
+	validField := rfVal.FieldByName("Valid")
+	valuer, isValuer := rfVal.Interface().(driver.Valuer)
+	if !validField.IsValid() || validField.Kind() != reflect.Bool || !isValuer {
+		return rfVal, false, false
+	}
+	if !validField.Bool() {
+		return rfVal, false, true
+	}
+
+	v, err := valuer.Value()
+	if err != nil || v == nil {
+		return rfVal, false, true
+	}
+	return reflect.ValueOf(v), true, true
+}
+
+// dialect returns the dialect set by WithDialect or the dialector name of db, e.g. mysql, postgres, sqlite
+func dialect(db *gorm.DB) string {
+	if db == nil {
+		return ""
+	}
+	if name, ok := db.Statement.Settings.Load(dialectSetting); ok { // Engine 指定的方言
+		return name.(string)
+	}
+	if db.Dialector == nil {
+		return ""
+	}
+	return db.Dialector.Name()
+}
+
+// SetTagKey changes the struct tag key from "filter"
+func SetTagKey(key string) {
+	std.tagKey = key
+	std.clearCache()
+}
+
+// SetTablePrefix sets the prefix of the tables of rules, such as app_ or a schema like analytics.
+func SetTablePrefix(prefix string) {
+	std.tablePrefix = prefix
+}
+
+// prefixTable returns table with the table prefix of the engine of db, or of SetTablePrefix if db is nil.
+// Empty tables stay empty
+func prefixTable(db *gorm.DB, table string) string {
+	if table == "" {
+		return ""
+	}
+	prefix := std.tablePrefix
+	if db != nil {
+		if v, ok := db.Statement.Settings.Load(tablePrefixSetting); ok { // Engine 指定的前缀
+			prefix = v.(string)
+		}
+	}
+	return prefix + table
+}
+
+// qualifier returns the qualifier of the columns of rule, its alias or its table with the table prefix of db
+func qualifier(db *gorm.DB, rule Rule) string {
+	if rule.Alias != "" {
+		return rule.Alias
+	}
+	return prefixTable(db, rule.Table)
+}
+
+// SetNamingStrategy sets the naming strategy deriving the columns of fields without json names or gorm columns
+func SetNamingStrategy(n schema.Namer) {
+	std.namer = n
+	std.clearCache()
+}
+
+// indirectType returns the element type of pointer types
+func indirectType(rt reflect.Type) reflect.Type {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt
+}
+
+// fieldName returns the json name of the field, falling back to the form or path name of go-zero requests,
+// the column of its gorm tag and then to the column derived by the naming strategy
+func (e *Engine) fieldName(field reflect.StructField) string {
+	if name := strings.TrimSpace(removeOmitempty(field.Tag.Get("json"))); name != "" {
+		return name
+	}
+	for _, key := range []string{"form", "path"} { // go-zero 请求结构体, 如 form:"name,optional"
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); strings.TrimSpace(name) != "" {
+			return strings.TrimSpace(name)
+		}
+	}
+	if column := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")["COLUMN"]; column != "" {
+		return column
+	}
+	return e.namer.ColumnName("", field.Name)
 }
 
 func removeOmitempty(tag string) string {
@@ -0,0 +1,88 @@
+package filter
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type benchFilter struct {
+	Name     string    `json:"name" filter:"opt:like"`
+	Email    string    `json:"email" filter:"opt:starts_with"`
+	Age      int       `json:"age" filter:"opt:>="`
+	Status   []int     `json:"status" filter:"opt:in"`
+	Role     string    `json:"role" filter:"table:roles"`
+	Created  time.Time `json:"created" filter:"column:created_at;opt:<"`
+	Nickname string    `json:"nickname" filter:"opt:like;logic:or"`
+	Phone    string    `json:"phone" filter:"opt:like;logic:or"`
+}
+
+var benchValue = benchFilter{
+	Name:     "john",
+	Email:    "john@",
+	Age:      18,
+	Status:   []int{1, 2, 3},
+	Role:     "admin",
+	Created:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	Nickname: "jo",
+	Phone:    "138",
+}
+
+func BenchmarkFilter(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		res, err := std.buildFilter(nil, benchValue)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = joinConditions(res.conditions)
+	}
+}
+
+func BenchmarkTypedFilter(b *testing.B) {
+	f := For[benchFilter]()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		res, err := buildRules(nil, f.rules, reflect.ValueOf(benchValue))
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = joinConditions(res.conditions)
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	rules := []Rule{
+		{Name: "name", Opt: Like},
+		{Name: "email", Opt: StartsWith},
+		{Name: "age", Opt: GTE},
+		{Name: "status", Opt: In},
+		{Name: "role", Table: "roles"},
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		res, err := std.buildSearch(nil, rules, benchValue)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = joinConditions(res.conditions)
+	}
+}
+
+func BenchmarkFromQuery(b *testing.B) {
+	rules := []Rule{
+		{Name: "name", Opt: Like},
+		{Name: "age", Opt: GTE},
+		{Name: "status", Opt: In},
+	}
+	values := url.Values{"name": {"john"}, "age": {"18"}, "status": {"1,2,3"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		res, err := buildQuery(nil, rules, values)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = joinConditions(res.conditions)
+	}
+}
@@ -1,7 +1,23 @@
 package filter
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"gorm.io/gorm/utils/tests"
 )
 
 type MockUser struct {
@@ -18,6 +34,33 @@ type MockUserFilter struct {
 // Mock database
 var db *gorm.DB
 
+// dryRun returns a database generating the SQL of queries without executing them
+func dryRun() *gorm.DB {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true, Logger: logger.Discard})
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// printSQL prints the SQL and vars of finding users with the scopes, or the error of the query
+func printSQL(scopes ...func(*gorm.DB) *gorm.DB) {
+	stmt := dryRun().Scopes(scopes...).Find(&[]MockUser{}).Statement
+	if stmt.Error != nil {
+		fmt.Println(stmt.Error)
+		return
+	}
+	fmt.Println(stmt.SQL.String(), stmt.Vars)
+}
+
+// printQueries makes db print the SQL and vars of its queries
+func printQueries(db *gorm.DB) *gorm.DB {
+	_ = db.Callback().Query().After("gorm:query").Register("example:print", func(tx *gorm.DB) {
+		fmt.Println(tx.Statement.SQL.String(), tx.Statement.Vars)
+	})
+	return db
+}
+
 func ExampleFilter() {
 	var users []MockUser
 	user := MockUserFilter{
@@ -43,3 +86,1387 @@ func ExampleMultiSearch() {
 	rule := []Rule{{Name: "name", Opt: "rlike"}, {Name: "age", Opt: "="}}
 	db.Scopes(MultiSearch(rule, keyword)).Find(&users)
 }
+
+func ExampleRegisterOperator() {
+	RegisterOperator("near", func(rule Rule, v reflect.Value) (string, []any) {
+		return "st_distance_sphere(" + rule.Name + ", point(?, ?)) < 1000", []any{v.Index(0).Interface(), v.Index(1).Interface()}
+	})
+
+	type ShopFilter struct {
+		Location []float64 `json:"location" filter:"opt:near"`
+	}
+	var shops []struct{}
+	db.Scopes(Filter(ShopFilter{Location: []float64{116.4, 39.9}})).Find(&shops)
+}
+
+func ExampleFilterE() {
+	var users []MockUser
+	user := MockUserFilter{
+		Name: "John",
+		Age:  20,
+	}
+	if err := db.Scopes(FilterE(user)).Find(&users).Error; err != nil {
+		return // invalid filter tags or values are returned as errors instead of panicking
+	}
+}
+
+func ExampleValidate() {
+	if err := Validate(MockUserFilter{}); err != nil {
+		panic(err) // typos like filter:"opt:lke" are reported at startup
+	}
+}
+
+func ExampleExplain() {
+	user := MockUserFilter{
+		Name: "John",
+		Age:  20,
+	}
+	query, params, _ := Explain(user)
+	fmt.Println(query, params)
+	// Output: name rlike ? AND age = ? [John 20]
+}
+
+func ExampleExplainSearch() {
+	user := MockUserFilter{
+		Name: "John",
+	}
+	rule := []Rule{{Name: "name", Opt: "like"}, {Name: "age", Opt: "="}}
+	query, params, _ := ExplainSearch(rule, user)
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' [%John%]
+}
+
+func ExampleExplainMultiSearch() {
+	rule := []Rule{{Name: "name", Opt: "rlike"}, {Name: "email", Opt: "starts_with", Logic: "or"}}
+	query, params, _ := ExplainMultiSearch(rule, "john")
+	fmt.Println(query, params)
+	// Output: (name rlike ? OR email like ? escape '!') [john john%]
+}
+
+func ExampleSetMultiSearchTokens() {
+	SetMultiSearchTokens(true)
+	defer SetMultiSearchTokens(false)
+
+	rule := []Rule{{Name: "name", Opt: "like"}, {Name: "email", Opt: "like"}}
+	query, params, _ := ExplainMultiSearch(rule, "john smith")
+	fmt.Println(query, params)
+	// Output: (name like ? escape '!' OR email like ? escape '!') AND (name like ? escape '!' OR email like ? escape '!') [%john% %john% %smith% %smith%]
+}
+
+func ExampleSetMultiSearchRelevance() {
+	SetMultiSearchRelevance(true)
+	defer SetMultiSearchRelevance(false)
+
+	rule := []Rule{{Name: "name", Opt: "like"}, {Name: "email", Opt: "like"}}
+	printSQL(MultiSearch(rule, "john"))
+	// Output: SELECT * FROM `mock_users` WHERE (`name` like ? escape '!' OR `email` like ? escape '!') ORDER BY case when (`name` = ? OR `email` = ?) then 0 when (`name` like ? escape '!' OR `email` like ? escape '!') then 1 else 2 end [%john% %john% john john john% john%]
+}
+
+func ExampleRule_type() {
+	rule := []Rule{{Name: "name", Opt: "like"}, {Name: "id", Type: TypeInt}}
+	query, params, _ := ExplainMultiSearch(rule, "john")
+	fmt.Println(query, params)
+	query, params, _ = ExplainMultiSearch(rule, "42")
+	fmt.Println(query, params)
+	// Output:
+	// name like ? escape '!' [%john%]
+	// (name like ? escape '!' OR id = ?) [%42% 42]
+}
+
+func ExampleRule_having() {
+	type OrderStats struct {
+		Status     int `json:"status" filter:"opt:="`
+		OrderCount int `json:"order_count" filter:"opt:>=;having:true"` // an aggregate alias
+	}
+	query, params, _ := Explain(OrderStats{Status: 1, OrderCount: 3})
+	fmt.Println(query, params)
+	// db.Model(&Order{}).Select("user_id, count(*) as order_count").Group("user_id").Scopes(Filter(stats))
+	// Output: status = ? HAVING order_count >= ? [1 3]
+}
+
+func ExampleOpt() {
+	type UserFilter struct {
+		Age Opt[int] `json:"age" filter:"opt:="` // 0 is used once set, unlike a plain int
+	}
+	var user UserFilter
+	_ = json.Unmarshal([]byte(`{"age":0}`), &user)
+	query, params, _ := Explain(user)
+	fmt.Println(query, params)
+	// Output: age = ? [0]
+}
+
+func ExampleRangeRule() {
+	type AgeFilter struct {
+		MinAge int `json:"min_age" filter:"opt:>=;column:age"`
+		MaxAge int `json:"max_age" filter:"opt:<=;column:age"`
+	}
+	filter := AgeFilter{MinAge: 18, MaxAge: 30}
+
+	query, params, _ := ExplainSearch(RangeRule("age"), filter)
+	fmt.Println(query, params)
+	query, params, _ = Explain(filter) // the same conditions from the tags
+	fmt.Println(query, params)
+	// Output:
+	// age >= ? AND age <= ? [18 30]
+	// age >= ? AND age <= ? [18 30]
+}
+
+func ExampleFilterMap() {
+	var values map[string]any
+	_ = json.Unmarshal([]byte(`{"name":"john","age":20,"unknown":1}`), &values)
+	rules := []Rule{{Name: "name", Opt: "like"}, {Name: "age", Opt: ">="}}
+	// db.Scopes(FilterMap(rules, values)).Find(&users)
+
+	query, params, _ := ExplainMap(rules, values)
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' AND age >= ? [%john% 20]
+}
+
+func ExampleFilterMap_zero() {
+	rules := []Rule{{Name: "age", Opt: ">="}, {Name: "active"}, {Name: "name"}}
+	query, params, _ := ExplainMap(rules, map[string]any{"age": 0.0, "active": false}) // 没有的键忽略
+	fmt.Println(query, params)
+	// Output: age >= ? AND active = ? [0 false]
+}
+
+func ExampleFromQuery() {
+	q, _ := url.ParseQuery("name=john&ids=1,2&day=2024-01-01,2024-01-31&page=2")
+	rules := []Rule{{Name: "name", Opt: "like"}, {Name: "ids", Column: "id", Opt: "in"}, {Name: "day", Column: "created_at", Opt: "date_range"}}
+	// db.Scopes(FromQuery(rules, q)).Find(&users)
+
+	query, params, _ := ExplainQuery(rules, q)
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' AND id IN (?,?) AND (created_at between ? and ?) [%john% 1 2 2024-01-01 00:00:00 2024-01-31 23:59:59]
+}
+
+func ExampleFromQuery_operator() {
+	q, _ := url.ParseQuery("age=gte:30&name=john")
+	rules := []Rule{{Name: "age", Opt: "=", Ops: []string{"gte", "lte"}}, {Name: "name", Opt: "like", Ops: []string{"eq"}}}
+
+	query, params, _ := ExplainQuery(rules, q)
+	fmt.Println(query, params)
+	// Output: age >= ? AND name like ? escape '!' [30 %john%]
+}
+
+func ExampleFromQuery_type() {
+	q, _ := url.ParseQuery("age=10&active=false&ids=1,2")
+	rules := []Rule{{Name: "age", Type: TypeInt}, {Name: "active", Type: TypeBool}, {Name: "ids", Column: "id", Opt: "in", Type: TypeInt}}
+
+	query, params, _ := ExplainQuery(rules, q)
+	fmt.Println(query, params)
+	fmt.Printf("%T %T %T\n", params[0], params[1], params[2])
+	_, _, err := ExplainQuery(rules, url.Values{"age": {"abc"}})
+	fmt.Println(err)
+	// Output:
+	// age = ? AND active = ? AND id IN (?,?) [10 false 1 2]
+	// int64 bool int64
+	// invalid filter value: age: strconv.ParseInt: parsing "abc": invalid syntax
+}
+
+func ExampleFromMongo() {
+	data := []byte(`{"age":{"$gte":30},"$or":[{"name":{"$like":"jo"}},{"email":"jo@example.com"}]}`)
+	rules := []Rule{{Name: "age", Ops: []string{"gte", "lte"}}, {Name: "name", Ops: []string{"like"}}, {Name: "email"}}
+	// db.Scopes(FromMongo(rules, data)).Find(&users)
+
+	query, params, _ := ExplainMongo(rules, data)
+	fmt.Println(query, params)
+	// Output: (name like ? escape '!' OR email = ?) AND age >= ? [%jo% jo@example.com 30]
+}
+
+func ExampleFromMongo_zero() {
+	rules := []Rule{{Name: "active"}, {Name: "name"}, {Name: "age", Ops: []string{"gt"}}}
+	query, params, _ := ExplainMongo(rules, []byte(`{"active":false,"name":"","age":{"$gt":0}}`)) // 写出的零值不忽略
+	fmt.Println(query, params)
+	// Output: active = ? AND age > ? AND name = ? [false 0 ]
+}
+
+func ExampleFromRSQL() {
+	filter := "age=ge=30;name=like=jo*,status=in=(a,b)"
+	rules := []Rule{{Name: "age", Ops: []string{"gte", "lte"}}, {Name: "name", Opt: "like"}, {Name: "status", Opt: "in"}}
+	// db.Scopes(FromRSQL(rules, filter)).Find(&users)
+
+	query, params, _ := ExplainRSQL(rules, filter)
+	fmt.Println(query, params)
+	// Output: ((age >= ? AND name like ? escape '!') OR status IN (?,?)) [30 jo% a b]
+}
+
+func ExampleFromRSQL_nesting() {
+	rules := []Rule{{Name: "age"}}
+	deep := strings.Repeat("(", 1_000_000) // 过深的嵌套返回错误, 不会耗尽栈
+	_, _, err := ExplainRSQL(rules, deep+"age==1")
+	fmt.Println(err)
+	_, _, err = ExplainOData(rules, deep+"age eq 1")
+	fmt.Println(err)
+	_, _, err = ExplainExpr(rules, strings.Repeat("not ", 1_000_000)+"age = 1")
+	fmt.Println(err)
+	// Output:
+	// invalid filter value: rsql: nesting deeper than 100 at position 101
+	// invalid filter value: odata: nesting deeper than 100 at position 101
+	// invalid filter value: expr: nesting deeper than 100 at position 404
+}
+
+func ExampleFromOData() {
+	filter := "age ge 30 and (contains(name,'jo') or status in ('a','b'))"
+	rules := []Rule{{Name: "age", Ops: []string{"gte", "lte"}}, {Name: "name", Opt: "like"}, {Name: "status", Opt: "in"}}
+	// db.Scopes(FromOData(rules, filter)).Find(&users)
+
+	query, params, _ := ExplainOData(rules, filter)
+	fmt.Println(query, params)
+	// Output: age >= ? AND (name like ? escape '!' OR status IN (?,?)) [30 %jo% a b]
+}
+
+func ExampleFromOData_zero() {
+	rules := []Rule{{Name: "active"}, {Name: "name"}}
+	query, params, _ := ExplainOData(rules, "active eq false and name eq ''") // 写出的零值不忽略
+	fmt.Println(query, params)
+	// Output: active = ? AND name = ? [false ]
+}
+
+func ExampleFromOData_type() {
+	rules := []Rule{{Name: "age", Type: TypeInt, Ops: []string{"in"}}, {Name: "id", Type: TypeUUID}, {Name: "deleted_at"}}
+	query, params, _ := ExplainOData(rules, "age in (18, 30) and deleted_at eq null")
+	fmt.Println(query, params)
+	fmt.Printf("%T\n", params[0])
+	_, _, err := ExplainOData(rules, "id eq 'abc'")
+	fmt.Println(err)
+	_, _, err = ExplainExpr(rules, "age = '3x'")
+	fmt.Println(err)
+	// Output:
+	// age IN (?,?) AND deleted_at IS NULL [18 30]
+	// int64
+	// invalid filter value: id: malformed uuid "abc"
+	// invalid filter value: age: strconv.ParseInt: parsing "3x": invalid syntax
+}
+
+func ExampleFromExpr() {
+	expr := `age > 30 and (name like "jo" or city = "NYC") and status not in ("banned", "closed")`
+	rules := []Rule{{Name: "age", Ops: []string{"gt", "lt"}}, {Name: "name", Opt: "like"}, {Name: "city"}, {Name: "status", Ops: []string{"not_in"}}}
+	// db.Scopes(FromExprE(rules, expr)).Find(&users)
+
+	query, params, _ := ExplainExpr(rules, expr)
+	fmt.Println(query, params)
+	_, _, err := ExplainExpr(rules, `age >= 30`)
+	fmt.Println(err)
+	// Output:
+	// age > ? AND (name like ? escape '!' OR city = ?) AND status NOT IN (?,?) [30 %jo% NYC banned closed]
+	// invalid filter value: operator ">=" is not allowed for age
+}
+
+func ExampleFromExpr_zero() {
+	rules := []Rule{{Name: "active"}, {Name: "age", Ops: []string{"gt"}}}
+	query, params, _ := ExplainExpr(rules, "not (active = false) and age > 0") // 写出的零值不忽略
+	fmt.Println(query, params)
+	// Output: NOT (active = ?) AND age > ? [false 0]
+}
+
+func ExampleC() {
+	cond := C("age", GTE, 30).And(C("name", Like, "jo").Or(C("city", Eq, "NYC")), C("status", In, []string{"a", "b"}).Not())
+	// db.Scopes(FromCond(cond)).Find(&users)
+
+	query, params, _ := ExplainCond(cond)
+	fmt.Println(query, params)
+	// Output: age >= ? AND (name like ? escape '!' OR city = ?) AND NOT (status IN (?,?)) [30 %jo% NYC a b]
+}
+
+func ExampleC_zero() {
+	query, params, _ := ExplainCond(C("active", Eq, false).And(C("deleted_at", Eq, nil)))
+	fmt.Println(query, params)
+	// Output: active = ? AND deleted_at IS NULL [false]
+}
+
+func ExampleFromJSONAPI() {
+	q, _ := url.ParseQuery("filter[name]=john&filter[age][gte]=30&filter[status]=a,b&page[size]=10")
+	rules := []Rule{{Name: "name", Opt: "like"}, {Name: "age", Ops: []string{"gte", "lte"}}, {Name: "status", Opt: "in"}}
+	// db.Scopes(FromJSONAPI(rules, q)).Find(&users)
+
+	query, params, _ := ExplainJSONAPI(rules, q)
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' AND age >= ? AND status IN (?,?) [%john% 30 a b]
+}
+
+func ExampleGraphQLInput() {
+	type UserFilter struct {
+		Name string  `json:"name" filter:"opt:like"`
+		Age  int     `json:"age" filter:"opt:>="`
+		IDs  []int64 `json:"ids" filter:"opt:in;column:id"`
+	}
+	schema, _ := GraphQLInput("UserFilter", UserFilter{})
+	fmt.Print(schema)
+
+	// in the resolver: db.Scopes(FromGraphQL(UserFilter{}, input)).Find(&users)
+	input := map[string]any{"name": "john", "ids": []any{1, 2}}
+	query, params, _ := ExplainGraphQL(UserFilter{}, input)
+	fmt.Println(query, params)
+	// Output:
+	// input UserFilter {
+	//   name: String
+	//   age: Int
+	//   ids: [Int]
+	// }
+	// name like ? escape '!' AND id IN (?,?) [%john% 1 2]
+}
+
+func ExampleGraphQLInput_special() {
+	type UserFilter struct {
+		Name string `json:"name" filter:"opt:like"`
+		Page int    `json:"page" filter:"page"` // 分页和排序是查询的参数, 不是过滤字段
+		Sort string `json:"sort" filter:"sort;allow:name"`
+	}
+	schema, _ := GraphQLInput("UserFilter", UserFilter{})
+	fmt.Print(schema)
+	query, params, _ := ExplainGraphQL(UserFilter{}, map[string]any{"page": 2, "sort": "name", "name": "x"})
+	fmt.Println(query, params)
+	// Output:
+	// input UserFilter {
+	//   name: String
+	// }
+	// name like ? escape '!' [%x%]
+}
+
+// mockGinContext binds a JSON body like gin's ShouldBind
+type mockGinContext struct {
+	body string
+}
+
+func (c mockGinContext) ShouldBind(obj any) error {
+	return json.Unmarshal([]byte(c.body), obj)
+}
+
+func ExampleBindGin() {
+	var users []MockUser
+	c := mockGinContext{body: `{"name":"John","age":20}`} // *gin.Context in a handler
+
+	var user MockUserFilter
+	scope, err := BindGin(c, &user)
+	if err != nil {
+		return // c.AbortWithStatusJSON(http.StatusBadRequest, ...)
+	}
+	db.Scopes(scope).Find(&users)
+}
+
+func ExampleFromRequest() {
+	type UserFilter struct {
+		Name string    `json:"name" filter:"opt:like"`
+		Age  int       `json:"age" filter:"opt:>="`
+		Day  [2]string `json:"day" filter:"opt:date_range;column:created_at"`
+	}
+	r := httptest.NewRequest("GET", "/users?name=john&age=30&day=2024-01-01,2024-01-31", nil)
+
+	var user UserFilter
+	scope, err := FromRequest(r, &user)
+	if err != nil {
+		return // http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+	_ = scope // db.Scopes(scope).Find(&users)
+
+	query, params, _ := Explain(user)
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' AND age >= ? AND (created_at between ? and ?) [%john% 30 2024-01-01 00:00:00 2024-01-31 23:59:59]
+}
+
+func ExampleFromRequest_location() {
+	type OrderFilter struct {
+		Since time.Time `json:"since" filter:"column:created_at;opt:>=;tz:Asia/Shanghai"` // 没有时区的时间按 tz 或 SetDateLocation 解析
+	}
+	r := httptest.NewRequest("GET", "/orders?since=2024-01-01", nil)
+
+	var order OrderFilter
+	if _, err := FromRequest(r, &order); err != nil {
+		return
+	}
+	fmt.Println(order.Since.UTC())
+	// Output: 2023-12-31 16:00:00 +0000 UTC
+}
+
+func ExampleFromRequest_blank() {
+	type UserFilter struct {
+		Age  *int  `json:"age" filter:"opt:="`
+		IDs  []int `json:"ids" filter:"column:id;opt:in;empty_none:true"`
+		Tags []int `json:"tags" filter:"opt:in;empty_none:true"`
+	}
+	// 空白的参数和元素被忽略, 不绑定为零值
+	r := httptest.NewRequest("GET", "/users?age=%20&ids=1,,2&tags=,", nil)
+
+	var user UserFilter
+	if _, err := FromRequest(r, &user); err != nil {
+		return
+	}
+	query, params, _ := Explain(user)
+	fmt.Println(user.Age == nil, user.Tags == nil, query, params)
+	// Output: true true id IN (?,?) [1 2]
+}
+
+func ExampleFilter_goZero() {
+	// go-zero request struct, bound by httpx.Parse
+	type ListUsersReq struct {
+		OrgID int64  `path:"org_id" filter:"opt:="`
+		Login string `form:"username,optional" filter:"opt:like"`
+		Role  string `form:"role,options=admin|user,optional" filter:"opt:="`
+	}
+	req := ListUsersReq{OrgID: 1, Login: "john", Role: "admin"}
+	// db.Scopes(Filter(req)).Find(&users)
+
+	query, params, _ := Explain(req)
+	fmt.Println(query, params)
+	// Output: org_id = ? AND username like ? escape '!' AND role = ? [1 %john% admin]
+}
+
+func ExamplePaginate() {
+	var users []MockUser
+	type UserFilter struct {
+		Name     string `json:"name" filter:"opt:like"`
+		Page     int    `json:"page" filter:"page"` // 分页字段不作为过滤条件
+		PageSize int    `json:"page_size" filter:"page_size"`
+	}
+	db.Scopes(Filter(UserFilter{Name: "john", Page: 2, PageSize: 10})).Find(&users)
+
+	// or paginate separately
+	db.Scopes(Filter(MockUserFilter{Name: "john"}), Paginate(2, 10)).Find(&users)
+}
+
+func ExampleFindPage() {
+	type UserFilter struct {
+		Name     string `json:"name" filter:"opt:like"`
+		Page     int    `json:"page" filter:"page"`
+		PageSize int    `json:"page_size" filter:"page_size"`
+	}
+	db := printQueries(dryRun())
+	var users []MockUser
+	var page Page // {"total":42,"page":2,"page_size":10,"items":[...]}
+	err := FindPage(db, &users, UserFilter{Name: "john", Page: 2, PageSize: 10}, &page)
+	fmt.Println(page.Total, page.Page, page.PageSize, err) // 没有数据时不再查询列表
+	// Output:
+	// SELECT count(*) FROM `mock_users` WHERE `name` like ? escape '!' [%john%]
+	// 0 2 10 <nil>
+}
+
+func ExampleFindPage_distinct() {
+	type OrderFilter struct {
+		Status int `json:"status" filter:"opt:="`
+	}
+	type UserFilter struct {
+		Order OrderFilter `json:"order" filter:"table:orders;join:user_id=id;distinct:true"`
+	}
+	db := printQueries(dryRun())
+	var users []MockUser
+	var page Page
+	_ = FindPage(db, &users, UserFilter{Order: OrderFilter{Status: 1}}, &page)
+	// Output: SELECT count(distinct `mock_users`.`id`) FROM `mock_users` join `orders` on `orders`.`user_id` = `mock_users`.`id` WHERE `orders`.`status` = ? [1]
+}
+
+func ExampleCount() {
+	db := printQueries(dryRun())
+	// 已添加的排序和分页不影响计数
+	list := db.Model(&MockUser{}).Order("age").Scopes(Paginate(2, 10))
+	total, err := Count(list, MockUserFilter{Name: "john"})
+	fmt.Println(total, err)
+	list.Scopes(FilterE(MockUserFilter{Name: "john"})).Find(&[]MockUser{})
+	// Output:
+	// SELECT count(*) FROM `mock_users` WHERE `name` rlike ? [john]
+	// 0 <nil>
+	// SELECT * FROM `mock_users` WHERE `name` rlike ? ORDER BY age LIMIT ? OFFSET ? [john 10 10]
+}
+
+func ExampleCount_distinct() {
+	type OrderFilter struct {
+		Status int `json:"status" filter:"opt:="`
+	}
+	type UserFilter struct {
+		Order OrderFilter `json:"order" filter:"table:orders;join:user_id=id;distinct:true"`
+	}
+	db := printQueries(dryRun())
+	// 一对多关联 join 后按主键去重计数
+	_, _ = Count(db.Model(&MockUser{}), UserFilter{Order: OrderFilter{Status: 1}})
+	// Output: SELECT count(distinct `mock_users`.`id`) FROM `mock_users` join `orders` on `orders`.`user_id` = `mock_users`.`id` WHERE `orders`.`status` = ? [1]
+}
+
+func ExampleKeyset() {
+	keyset := Keyset{Columns: []string{"age", "id"}, Desc: true, Size: 20}
+	printSQL(Filter(MockUserFilter{Name: "john"}), keyset.Paginate("")) // 第一页
+
+	// 上一页最后一行的游标, 返回给客户端用于请求下一页
+	next, _ := keyset.Cursor(MockUser{ID: 7, Age: 30})
+	printSQL(Filter(MockUserFilter{Name: "john"}), keyset.Paginate(next))
+	// Output:
+	// SELECT * FROM `mock_users` WHERE `name` rlike ? ORDER BY `age` DESC,`id` DESC LIMIT ? [john 20]
+	// SELECT * FROM `mock_users` WHERE `name` rlike ? AND (`age`, `id`) < (?, ?) ORDER BY `age` DESC,`id` DESC LIMIT ? [john 30 7 20]
+}
+
+func ExampleSort() {
+	var users []MockUser
+	orderBy := "age desc,name" // or "-age,name", e.g. from ?sort=
+	db.Scopes(Filter(MockUserFilter{Name: "john"}), Sort(orderBy, "name", "age", "created_at")).Find(&users)
+}
+
+func ExampleFilter_sort() {
+	var users []MockUser
+	type UserFilter struct {
+		Name string `json:"name" filter:"opt:like"`
+		Sort string `json:"sort" filter:"sort;allow:name,age,created_at"` // 排序字段不作为过滤条件
+	}
+	db.Scopes(Filter(UserFilter{Name: "john", Sort: "-age,name"})).Find(&users)
+}
+
+func ExampleSelect() {
+	var users []MockUser
+	fields := []string{"id", "name"} // e.g. from ?fields=id,name
+	db.Scopes(Filter(MockUserFilter{Name: "john"}), Select(fields, "id", "name", "age")).Find(&users)
+
+	// or as a field of the filter struct
+	type UserFilter struct {
+		Name   string   `json:"name" filter:"opt:like"`
+		Fields []string `json:"fields" filter:"fields;allow:id,name,age"`
+	}
+	db.Scopes(Filter(UserFilter{Name: "john", Fields: fields})).Find(&users)
+}
+
+func ExampleFor() {
+	// parse the tags once, e.g. in a package variable
+	userFilter := For[MockUserFilter]()
+
+	var users []MockUser
+	userFilter.Apply(db, MockUserFilter{Name: "john"}).Find(&users)
+	db.Scopes(userFilter.Scope(MockUserFilter{Name: "john"})).Find(&users)
+}
+
+func ExampleGroupBy() {
+	type Stat struct {
+		Status int
+		Count  int
+	}
+	var stats []Stat
+	group := []string{"status"} // e.g. from ?group=status
+	db.Model(&MockUser{}).Select("status, count(*) as count").
+		Scopes(Filter(MockUserFilter{Age: 20}), GroupBy(group, []string{"status", "city"})).
+		Scan(&stats)
+}
+
+func ExampleFilter_join() {
+	type OrderFilter struct {
+		Status int `json:"status" filter:"opt:="`
+	}
+	type UserFilter struct {
+		Name string `json:"name" filter:"opt:like"`
+		// users have many orders, distinct keeps one row per user once orders are joined
+		Order OrderFilter `json:"order" filter:"table:orders;join:user_id=id;distinct:true"`
+	}
+	printSQL(Filter(UserFilter{Name: "jo", Order: OrderFilter{Status: 1}}))
+	// Output: SELECT DISTINCT `mock_users`.`id`,`mock_users`.`name`,`mock_users`.`age` FROM `mock_users` join `orders` on `orders`.`user_id` = `mock_users`.`id` WHERE `name` like ? escape '!' AND `orders`.`status` = ? [%jo% 1]
+}
+
+func ExampleFilter_unscoped() {
+	type UserFilter struct {
+		Name           string `json:"name" filter:"opt:like"`
+		IncludeDeleted bool   `json:"include_deleted" filter:"opt:unscoped"` // calls db.Unscoped() when true
+		OnlyDeleted    bool   `json:"only_deleted" filter:"column:deleted_at;opt:not_null"`
+	}
+	var users []MockUser
+	// ?include_deleted=true: all rows, ?include_deleted=true&only_deleted=true: deleted rows only
+	db.Scopes(Filter(UserFilter{IncludeDeleted: true, OnlyDeleted: true})).Find(&users)
+}
+
+func ExampleRequireScope() {
+	type tenantKey struct{}
+	RequireScope(func(ctx context.Context) (Rule, any, bool) {
+		tenant, ok := ctx.Value(tenantKey{}).(int64)
+		return Rule{Name: "tenant_id"}, tenant, ok
+	})
+
+	var users []MockUser
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(42))
+	// SELECT * FROM users WHERE tenant_id = 42 AND name rlike 'john'
+	db.WithContext(ctx).Scopes(Filter(MockUserFilter{Name: "john"})).Find(&users)
+	// without a tenant the query fails with ErrMissingScope
+	db.Scopes(Filter(MockUserFilter{Name: "john"})).Find(&users)
+}
+
+func ExampleRequireAtLeast() {
+	RequireAtLeast(1)
+	defer RequireAtLeast(0)
+
+	// an empty filter fails with ErrUnfiltered instead of selecting every user
+	printSQL(Filter(MockUserFilter{}))
+	printSQL(Filter(MockUserFilter{Name: "john"}))
+	// Output:
+	// too few filter conditions: 0 of 1
+	// SELECT * FROM `mock_users` WHERE `name` rlike ? [john]
+}
+
+func ExampleSetMaxInSize() {
+	SetMaxInSize(2, true)
+	defer SetMaxInSize(0, false)
+
+	type UserFilter struct {
+		IDs []int `json:"ids" filter:"column:id;opt:in"`
+	}
+	query, params, _ := Explain(UserFilter{IDs: []int{1, 2, 3}})
+	fmt.Println(query, params)
+	// Output: (id IN (?,?) OR id = ?) [1 2 3]
+}
+
+func ExampleRegisterTransform() {
+	RegisterTransform("digits", func(v any) any { // keeps the digits of phone numbers
+		s, ok := v.(string)
+		if !ok {
+			return v
+		}
+		return strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return -1
+			}
+			return r
+		}, s)
+	})
+
+	type UserFilter struct {
+		Email string `json:"email" filter:"transform:trim,lower"`
+		Phone string `json:"phone" filter:"transform:digits"`
+	}
+	query, params, _ := Explain(UserFilter{Email: " John@Example.com ", Phone: "+1 (555) 010-0199"})
+	fmt.Println(query, params)
+	// Output: email = ? AND phone = ? [john@example.com 15550100199]
+}
+
+func ExampleFilter_map() {
+	type OrderFilter struct {
+		Status   string   `json:"status" filter:"opt:=;map:active=1|inactive=0"`
+		Statuses []string `json:"statuses" filter:"column:status;opt:in;map:active=1|inactive=0"`
+	}
+	query, params, _ := Explain(OrderFilter{Status: "inactive", Statuses: []string{"active", "inactive"}})
+	fmt.Println(query, params)
+	_, _, err := Explain(OrderFilter{Status: "deleted"})
+	fmt.Println(err)
+	// Output:
+	// status = ? AND status IN (?,?) [0 1 0]
+	// invalid filter value: status: unknown value "deleted"
+}
+
+func ExampleSetDefaultTransforms() {
+	SetDefaultTransforms("collapse")
+	defer SetDefaultTransforms()
+
+	type UserFilter struct {
+		Name  string `json:"name" filter:"opt:like"`
+		Email string `json:"email" filter:"transform:lower"` // after the default transforms
+	}
+	query, params, _ := Explain(UserFilter{Name: " John   Smith ", Email: "John@Example.com "})
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' AND email = ? [%John Smith% john@example.com]
+}
+
+func ExampleFilter_default() {
+	type OrderFilter struct {
+		Status string `json:"status" filter:"opt:=;default:pending"` // status = 'pending' unless given
+		Type   []int  `json:"type" filter:"opt:in;default:1,2"`
+	}
+	query, params, _ := Explain(OrderFilter{})
+	fmt.Println(query, params)
+	query, params, _ = Explain(OrderFilter{Status: "paid", Type: []int{3}})
+	fmt.Println(query, params)
+	// Output:
+	// status = ? AND type IN (?,?) [pending 1 2]
+	// status = ? AND type = ? [paid 3]
+}
+
+func ExampleFilter_when() {
+	type DocumentFilter struct {
+		Type    string `json:"type" filter:"opt:="`
+		Number  string `json:"number" filter:"column:order_no;when:type=order"` // only for orders
+		Overdue bool   `json:"overdue" filter:"column:paid_at;opt:is_null;when:type=invoice|receipt"`
+	}
+	query, params, _ := Explain(DocumentFilter{Type: "order", Number: "A1", Overdue: true})
+	fmt.Println(query, params)
+	query, params, _ = Explain(DocumentFilter{Type: "invoice", Number: "A1", Overdue: true})
+	fmt.Println(query, params)
+	// Output:
+	// type = ? AND order_no = ? [order A1]
+	// type = ? AND paid_at IS NULL [invoice]
+}
+
+func ExampleParseRules() {
+	// e.g. from a config file
+	rules, err := ParseRules([]byte(`[
+		{"name": "name", "opt": "like"},
+		{"name": "status", "opt": "=", "default": "active", "map": {"active": 1, "disabled": 0}}
+	]`))
+	if err != nil {
+		panic(err)
+	}
+	query, params, _ := ExplainMap(rules, map[string]any{"name": "john"})
+	fmt.Println(query, params)
+
+	data, _ := json.Marshal(rules[0])
+	fmt.Println(string(data))
+	_, err = ParseRules([]byte(`[{"name": "age", "opt": "~"}]`))
+	fmt.Println(err != nil)
+	// Output:
+	// name like ? escape '!' AND status = ? [%john% 1]
+	// {"name":"name","opt":"like"}
+	// true
+}
+
+func ExampleParseRules_trusted() {
+	// 存储的规则不能跳过名称校验或使用 SQL 片段
+	_, err := ParseRules([]byte(`[{"name": "x", "column": "1; drop table users", "trusted": true}]`))
+	fmt.Println(err)
+	_, err = ParseRules([]byte(`[{"name": "x", "opt": "raw", "sql": "1 = 1 or ?"}]`))
+	fmt.Println(err)
+	// Output:
+	// rule 0: invalid filter column: "1; drop table users"
+	// rule 0: invalid filter tag: raw rule requires sql
+}
+
+func ExampleFilter_raw() {
+	type PlayerFilter struct {
+		Level   int  `json:"level" filter:"opt:raw;sql:JSON_EXTRACT(meta, '$.level') >= ?"`
+		InStock bool `json:"in_stock" filter:"opt:raw;sql:stock > 0"` // 无占位符, 字段非零值时生效
+	}
+	query, params, _ := Explain(PlayerFilter{Level: 3, InStock: true})
+	fmt.Println(query, params)
+	// Output: JSON_EXTRACT(meta, '$.level') >= ? AND stock > 0 [3]
+}
+
+func ExampleFilter_subquery() {
+	type MockOrder struct {
+		ID     int
+		UserID int
+		Status string
+	}
+	type UserFilter struct {
+		IDs  *gorm.DB `json:"-" filter:"column:id;opt:in"` // nil 时忽略
+		Name string   `json:"name" filter:"opt:like"`
+	}
+	var users []MockUser
+	paid := db.Model(&MockOrder{}).Select("user_id").Where("status = ?", "paid")
+	// id IN (SELECT user_id FROM mock_orders WHERE status = 'paid') AND name like '%john%'
+	db.Scopes(Filter(UserFilter{IDs: paid, Name: "john"})).Find(&users)
+}
+
+func ExampleFilter_not() {
+	type ProductFilter struct {
+		Category string `json:"category" filter:"opt:="`
+		Brand    string `json:"brand" filter:"opt:=;group:excluded;not:true"` // NOT (brand = ? AND color = ?)
+		Color    string `json:"color" filter:"opt:=;group:excluded"`
+		Name     string `json:"name" filter:"opt:like;not:true"`
+	}
+	query, params, _ := Explain(ProductFilter{Category: "shoes", Brand: "acme", Color: "red", Name: "kids"})
+	fmt.Println(query, params)
+	query, params, _ = Explain(ProductFilter{Category: "shoes", Brand: "acme"})
+	fmt.Println(query, params)
+	// Output:
+	// category = ? AND NOT (brand = ? AND color = ?) AND NOT (name like ? escape '!') [shoes acme red %kids%]
+	// category = ? AND NOT (brand = ?) [shoes acme]
+}
+
+func ExampleNew() {
+	type ProductFilter struct {
+		Name     string `json:"name" search:"column:title"` // 未指定 opt 时使用 like
+		Category string `json:"category" search:"opt:="`
+	}
+	engine := New(WithTagKey("search"), WithDefaultOpt(Like), WithStrict(true))
+	printSQL(engine.FilterE(ProductFilter{Name: "phone", Category: "mobile"}))
+	// Output: SELECT * FROM `mock_users` WHERE `title` like ? escape '!' AND `category` = ? [%phone% mobile]
+}
+
+func ExampleEngine_Filter() {
+	type UserFilter struct {
+		Name string `json:"name" filter:"table:users;opt:ilike"`
+	}
+	engine := New(WithTablePrefix("x_"), WithDialect("postgres"))
+	// 引擎的方言和表前缀只用于它自己的条件
+	printSQL(engine.Filter(UserFilter{Name: "jo"}), Filter(UserFilter{Name: "ann"}))
+	// Output: SELECT * FROM `mock_users` WHERE `x_users`.`name` ilike ? escape '!' AND lower(`users`.`name`) like lower(?) escape '!' [%jo% %ann%]
+}
+
+func ExampleSetTablePrefix() {
+	SetTablePrefix("app_")
+	defer SetTablePrefix("")
+
+	type OrderFilter struct {
+		UserName string `json:"user_name" filter:"table:users;column:name;opt:like"` // app_users.name
+		Status   string `json:"status" filter:"opt:="`                               // 未指定 table 的列不受影响
+	}
+	query, params, _ := Explain(OrderFilter{UserName: "john", Status: "paid"})
+	fmt.Println(query, params)
+	// Output: app_users.name like ? escape '!' AND status = ? [%john% paid]
+}
+
+func ExampleFilter_alias() {
+	type OrderFilter struct {
+		UserName string `json:"user_name" filter:"table:users;alias:u;column:name;opt:like"` // 查询中 JOIN users u
+		Status   string `json:"status" filter:"alias:o;opt:="`
+	}
+	query, params, _ := Explain(OrderFilter{UserName: "john", Status: "paid"})
+	fmt.Println(query, params)
+	// Output: u.name like ? escape '!' AND o.status = ? [%john% paid]
+}
+
+func ExampleFilter_rel() {
+	type MockOrder struct {
+		ID     int
+		UserID int
+		Status string
+	}
+	type User struct {
+		ID     int
+		Name   string
+		Orders []MockOrder `gorm:"foreignKey:UserID"`
+	}
+	type UserFilter struct {
+		OrderStatus string `json:"order_status" filter:"rel:Orders;column:status"` // 关联名为模型的字段名
+	}
+	var users []User
+	// users.id IN (SELECT mock_orders.user_id FROM mock_orders WHERE mock_orders.status = 'paid')
+	db.Scopes(Filter(UserFilter{OrderStatus: "paid"})).Find(&users)
+}
+
+func ExampleFilter_slice() {
+	keys := []MockUserFilter{{Name: "john", Age: 20}, {Name: "jane", Age: 30}}
+	// db.Scopes(Filter(keys)).Find(&users)
+
+	query, params, _ := Explain(keys)
+	fmt.Println(query, params)
+	// Output: ((name rlike ? AND age = ?) OR (name rlike ? AND age = ?)) [john 20 jane 30]
+}
+
+func ExampleFilter_or() {
+	var users []MockUser
+	// WHERE (role = 'admin' OR role = 'owner') AND name rlike 'john', instead of restricting only role = 'owner'
+	db.Where("role = ?", "admin").Or("role = ?", "owner").Scopes(Filter(MockUserFilter{Name: "john"})).Find(&users)
+}
+
+func ExampleOr() {
+	var users []MockUser
+	permission := func(db *gorm.DB) *gorm.DB { return db.Where("tenant_id = ?", 1) }
+	userFilter := MockUserFilter{Name: "john", Age: 20}
+	fallback := MockUserFilter{Name: "guest"}
+	// WHERE tenant_id = 1 AND ((name rlike 'john' AND age = 20) OR name rlike 'guest')
+	db.Scopes(And(permission, Or(Filter(userFilter), Filter(fallback)))).Find(&users)
+}
+
+func ExampleFilterAny() {
+	var users []MockUser
+	// WHERE name rlike 'john' OR age = 20
+	db.Scopes(FilterAny(MockUserFilter{Name: "john", Age: 20})).Find(&users)
+}
+
+func ExampleRegisterValidator() {
+	RegisterValidator("short", func(v any) error {
+		if s, _ := v.(string); len(s) > 8 {
+			return fmt.Errorf("%q is longer than 8 characters", s)
+		}
+		return nil
+	})
+	type OrderFilter struct {
+		ID   string `json:"id" filter:"validate:uuid"`
+		Code string `json:"code" filter:"validate:short"`
+		Page int    `json:"page" filter:"page;validate:positive"`
+	}
+	_, _, err := Explain(OrderFilter{ID: "123"})
+	fmt.Println(err)
+	_, _, err = Explain(OrderFilter{Code: "ABCDEFGHIJ"})
+	fmt.Println(err)
+	_, _, err = Explain(OrderFilter{Page: -1})
+	fmt.Println(err)
+	// Output:
+	// invalid filter value: id: malformed uuid "123"
+	// invalid filter value: code: "ABCDEFGHIJ" is longer than 8 characters
+	// invalid filter value: page: -1 is not positive
+}
+
+func ExampleExplainSkipped() {
+	type UserFilter struct {
+		Name   string `json:"name" filter:"opt:like"`
+		Age    int    `json:"age" filter:"opt:>"`
+		Status string `json:"status" filter:"opt:equals"`
+		Email  string `json:"email"`
+	}
+	skipped, _ := ExplainSkipped(UserFilter{Name: "john", Status: "active"})
+	for _, s := range skipped {
+		fmt.Printf("%s: %s\n", s.Field, s.Reason)
+	}
+	// Output:
+	// Email: no filter tag
+	// Age: zero value
+	// Status: unknown operator
+}
+
+func ExampleDiagnose() {
+	var users []MockUser
+	tx := db.Scopes(Diagnose, Filter(MockUserFilter{Name: "john"})).Find(&users)
+	for _, s := range Skipped(tx) {
+		fmt.Printf("%s: %s\n", s.Field, s.Reason) // Age: zero value
+	}
+}
+
+func ExampleSetLogger() {
+	SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer SetLogger(nil)
+	var users []MockUser
+	// level=DEBUG msg="filter: skipped" field=Age name=age reason="zero value"
+	// level=DEBUG msg="filter: conditions" sql="name rlike ?" params=1 rules=[name]
+	db.Scopes(Filter(MockUserFilter{Name: "john"})).Find(&users)
+}
+
+func ExampleSetSpanAnnotator() {
+	SetSpanAnnotator(func(ctx context.Context, attrs map[string]any) {
+		// 如 OpenTelemetry 的 trace.SpanFromContext(ctx).SetAttributes(...)
+		fmt.Println(attrs[AttrColumns], attrs[AttrOperators], attrs[AttrConditions]) // [name age] [rlike =] 2
+	})
+	defer SetSpanAnnotator(nil)
+	var users []MockUser
+	db.WithContext(context.Background()).Scopes(Filter(MockUserFilter{Name: "john", Age: 20})).Find(&users)
+}
+
+func ExampleOnApply() {
+	OnApply(func(stats FilterStats) {
+		// 如 Prometheus 的 filterQueries.WithLabelValues(stats.Source).Inc()
+		fmt.Println(stats.Source, stats.Conditions, stats.Operators) // filter.MockUserFilter 1 [rlike]
+	})
+	var users []MockUser
+	db.Scopes(Filter(MockUserFilter{Name: "john"})).Find(&users)
+}
+
+func ExampleSetEmptyNone() {
+	type OrderFilter struct {
+		IDs []int `json:"ids" filter:"opt:in;empty_none:true"` // 或 SetEmptyNone(true) 用于所有 in 规则
+	}
+	allowed := []int{} // 如权限系统允许访问的订单, 为空时不应返回所有订单
+	query, _, _ := Explain(OrderFilter{IDs: allowed})
+	fmt.Println(query)
+	query, _, _ = Explain(OrderFilter{}) // nil 切片表示未设置, 仍然忽略
+	fmt.Println(query == "")
+	// Output:
+	// 1 = 0
+	// true
+}
+
+func ExampleFilter_zeroKinds() {
+	type ProductFilter struct {
+		Name    string `json:"name" filter:"opt:like;use_zero:int|bool"`
+		Stock   int    `json:"stock" filter:"use_zero:int|bool"`       // 0 表示缺货, 仍然过滤
+		OnSale  bool   `json:"on_sale" filter:"use_zero:int|bool"`     // false 仍然过滤
+		Keyword string `json:"keyword" filter:"column:title;opt:like"` // 空字符串忽略
+	}
+	query, params, _ := Explain(ProductFilter{})
+	fmt.Println(query, params)
+	// Output: stock = ? AND on_sale = ? [0 false]
+}
+
+func ExampleFilter_uuid() {
+	type OrderFilter struct {
+		UserID  [16]byte `json:"user_id" filter:"opt:="`      // 或 uuid.UUID, uuid.Nil 忽略
+		OrderID string   `json:"order_id" filter:"type:uuid"` // 字符串值必须是 UUID
+	}
+	query, _, _ := Explain(OrderFilter{})
+	fmt.Println(query == "")
+	_, _, err := Explain(OrderFilter{OrderID: "42"})
+	fmt.Println(err)
+	// Output:
+	// true
+	// invalid filter value: order_id: malformed uuid "42"
+}
+
+// exampleDecimal stands in for decimal.Decimal, a struct implementing driver.Valuer with a numeric string value
+type exampleDecimal struct{ s string }
+
+func (d exampleDecimal) Value() (driver.Value, error) { return d.s, nil }
+
+func ExampleFilter_decimal() {
+	type OrderFilter struct {
+		MinAmount exampleDecimal `json:"min_amount" filter:"column:amount;opt:>=;validate:non_negative"`
+		MaxAmount exampleDecimal `json:"max_amount" filter:"column:amount;opt:<="`
+	}
+	query, params, _ := Explain(OrderFilter{MinAmount: exampleDecimal{"0.00"}, MaxAmount: exampleDecimal{"99.90"}})
+	fmt.Println(query, params) // 0.00 为零值, 忽略
+	_, _, err := Explain(OrderFilter{MinAmount: exampleDecimal{"-1.5"}})
+	fmt.Println(err)
+	// Output:
+	// amount <= ? [{99.90}]
+	// invalid filter value: min_amount: {-1.5} is not non-negative
+}
+
+func ExampleFilter_bool3() {
+	type UserFilter struct {
+		Active   *bool  `json:"active" filter:"opt:="`         // nil 忽略, false 比较 active = false
+		Verified string `json:"verified" filter:"opt:bool3"`   // 如 ?verified=false, 空字符串忽略
+		Admin    bool   `json:"admin" filter:"use_zero:false"` // 普通 bool 的 false 为零值, 忽略
+	}
+	active := false
+	query, params, _ := Explain(UserFilter{Active: &active, Verified: "false"})
+	fmt.Println(query, params)
+	query, _, _ = Explain(UserFilter{})
+	fmt.Println(query == "")
+	_, _, err := Explain(UserFilter{Verified: "maybe"})
+	fmt.Println(err)
+	// Output:
+	// active = ? AND verified = ? [false false]
+	// true
+	// invalid filter value: bool3 rule requires true or false
+}
+
+func ExampleFilter_colCmp() {
+	type EventFilter struct {
+		EndsAfter string `json:"ends_after" filter:"column:end_at;opt:col_cmp;cmp:>;cols:start_at,deadline"` // 只能比较允许的列
+	}
+	query, params, _ := Explain(EventFilter{EndsAfter: "start_at"})
+	fmt.Println(query, params)
+	_, _, err := Explain(EventFilter{EndsAfter: "password_hash"})
+	fmt.Println(err)
+	// Output:
+	// end_at > start_at []
+	// invalid filter value: col_cmp column "password_hash" is not allowed
+}
+
+func ExampleFilter_in() {
+	type UserFilter struct {
+		IDs     []int    `json:"ids" filter:"column:id;opt:in"`
+		Exclude []string `json:"exclude" filter:"column:role;opt:not_in;use_zero:true"` // 空列表不排除任何行
+	}
+	query, params, _ := Explain(UserFilter{IDs: []int{1, 2}, Exclude: []string{"admin", "guest"}})
+	fmt.Println(query, params)
+	query, params, _ = Explain(UserFilter{Exclude: []string{}})
+	fmt.Println(query == "", params)
+	// Output:
+	// id IN (?,?) AND role NOT IN (?,?) [1 2 admin guest]
+	// true []
+}
+
+func ExampleFilter_logicOr() {
+	type UserFilter struct {
+		Status int    `json:"status" filter:"opt:="`
+		Name   string `json:"name" filter:"opt:like;logic:or"` // name 或 email 匹配即可
+		Email  string `json:"email" filter:"opt:like;logic:or"`
+	}
+	query, params, _ := Explain(UserFilter{Status: 1, Name: "jo", Email: "jo"})
+	fmt.Println(query, params)
+	// Output: status = ? AND (name like ? escape '!' OR email like ? escape '!') [1 %jo% %jo%]
+}
+
+func ExampleFilter_group() {
+	type UserFilter struct {
+		Status int    `json:"status" filter:"opt:="`
+		Name   string `json:"name" filter:"opt:like;group:keyword;logic:or;group_logic:or"` // 组内和组之间都是 OR
+		Email  string `json:"email" filter:"opt:like;group:keyword;logic:or;group_logic:or"`
+		Role   string `json:"role" filter:"opt:=;group:owner;group_logic:or"`
+		Admin  bool   `json:"admin" filter:"column:is_admin;opt:=;group:owner;group_logic:or"`
+	}
+	query, params, _ := Explain(UserFilter{Status: 1, Name: "jo", Email: "jo", Role: "owner", Admin: true})
+	fmt.Println(query, params)
+	// Output: status = ? AND ((name like ? escape '!' OR email like ? escape '!') OR (role = ? AND is_admin = ?)) [1 %jo% %jo% owner true]
+}
+
+func ExampleFilter_neq() {
+	type UserFilter struct {
+		Status int `json:"status" filter:"opt:!=;use_zero:true"`
+	}
+	query, params, _ := Explain(UserFilter{Status: 0})
+	fmt.Println(query, params)
+	// Output: status <> ? [0]
+}
+
+func ExampleFilter_isNull() {
+	type UserFilter struct {
+		Deleted bool `json:"deleted" filter:"column:deleted_at;opt:not_null"` // 字段值为 true 时生成条件
+		Active  bool `json:"active" filter:"column:deleted_at;opt:is_null"`
+	}
+	query, params, _ := Explain(UserFilter{Deleted: true})
+	fmt.Println(query, params)
+	query, params, _ = Explain(UserFilter{Active: true})
+	fmt.Println(query, params)
+	query, params, _ = Explain(UserFilter{})
+	fmt.Println(query == "", params)
+	// Output:
+	// deleted_at IS NOT NULL []
+	// deleted_at IS NULL []
+	// true []
+}
+
+func ExampleFilter_notLike() {
+	type UserFilter struct {
+		Name string `json:"name" filter:"opt:not_like"`
+	}
+	query, params, _ := Explain(UserFilter{Name: "test"})
+	fmt.Println(query, params)
+	// Output: name not like ? escape '!' [%test%]
+}
+
+func ExampleFilter_startsWith() {
+	type UserFilter struct {
+		Name  string `json:"name" filter:"opt:starts_with"`
+		Email string `json:"email" filter:"opt:ends_with"`
+	}
+	query, params, _ := Explain(UserFilter{Name: "jo", Email: "@example.com"})
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' AND email like ? escape '!' [jo% %@example.com]
+}
+
+func ExampleFilter_ilike() {
+	type UserFilter struct {
+		Name string `json:"name" filter:"opt:ilike"`
+	}
+	printSQL(Filter(UserFilter{Name: "Jo"}))
+	printSQL(New(WithDialect("postgres")).Filter(UserFilter{Name: "Jo"}))
+	// Output:
+	// SELECT * FROM `mock_users` WHERE lower(`name`) like lower(?) escape '!' [%Jo%]
+	// SELECT * FROM `mock_users` WHERE `name` ilike ? escape '!' [%Jo%]
+}
+
+func ExampleFilter_between() {
+	type ProductFilter struct {
+		Price []int `json:"price" filter:"opt:between"`
+		Stock Range `json:"stock" filter:"opt:between"` // 只有一个边界时为 >= 或 <=
+	}
+	query, params, _ := Explain(ProductFilter{Price: []int{10, 100}, Stock: Range{Min: 5}})
+	fmt.Println(query, params)
+	// Output: (price between ? and ?) AND stock >= ? [10 100 5]
+}
+
+func ExampleFilter_datetimeRange() {
+	type OrderFilter struct {
+		CreatedAt []string `json:"created_at" filter:"opt:datetime_range"`
+		PaidAt    []string `json:"paid_at" filter:"opt:datetime_range"`
+	}
+	query, params, _ := Explain(OrderFilter{
+		CreatedAt: []string{"2024-01-01 08:00:00", "2024-01-01 18:30:00"},
+		PaidAt:    []string{"2024-01-01 08:00:00", ""},
+	})
+	fmt.Println(query, params)
+	// Output: (created_at between ? and ?) AND paid_at >= ? [2024-01-01 08:00:00 2024-01-01 18:30:00 2024-01-01 08:00:00]
+}
+
+func ExampleFilter_lastDays() {
+	type OrderFilter struct {
+		CreatedAt int `json:"created_at" filter:"opt:last_days"`
+		UpdatedAt int `json:"updated_at" filter:"opt:last_hours"`
+	}
+	query, params, _ := Explain(OrderFilter{CreatedAt: 7, UpdatedAt: 2})
+	fmt.Println(query)
+	fmt.Println(time.Since(params[0].(time.Time)).Round(time.Hour), time.Since(params[1].(time.Time)).Round(time.Hour))
+	// Output:
+	// created_at >= ? AND updated_at >= ?
+	// 168h0m0s 2h0m0s
+}
+
+func ExampleFilter_jsonContains() {
+	type ProductFilter struct {
+		Attrs map[string]string `json:"attrs" filter:"opt:json_contains"`
+	}
+	printSQL(Filter(ProductFilter{Attrs: map[string]string{"color": "red"}}))
+	printSQL(New(WithDialect("postgres")).Filter(ProductFilter{Attrs: map[string]string{"color": "red"}}))
+	// Output:
+	// SELECT * FROM `mock_users` WHERE json_contains(`attrs`, ?) [{"color":"red"}]
+	// SELECT * FROM `mock_users` WHERE `attrs` @> ? [{"color":"red"}]
+}
+
+func ExampleFilter_array() {
+	type PostFilter struct {
+		Tags []string `json:"tags" filter:"opt:array_contains"`
+		Tag  string   `json:"tag" filter:"column:tags;opt:array_any"`
+	}
+	query, params, _ := Explain(PostFilter{Tags: []string{"go", "sql"}, Tag: "orm"})
+	fmt.Println(query, params)
+	// Output: tags @> ? AND ? = any(tags) [{"go","sql"} orm]
+}
+
+func ExampleFilter_regexp() {
+	type UserFilter struct {
+		Name string `json:"name" filter:"opt:regexp"`
+	}
+	printSQL(Filter(UserFilter{Name: "^jo"}))
+	printSQL(New(WithDialect("postgres")).Filter(UserFilter{Name: "^jo"}))
+	// Output:
+	// SELECT * FROM `mock_users` WHERE `name` regexp ? [^jo]
+	// SELECT * FROM `mock_users` WHERE `name` ~ ? [^jo]
+}
+
+func ExampleFilter_exists() {
+	type UserFilter struct {
+		OrderStatus string `json:"order_status" filter:"column:status;opt:exists;table:orders;fk:user_id;ref:id"`
+	}
+	printSQL(Filter(UserFilter{OrderStatus: "paid"}))
+	// Output: SELECT * FROM `mock_users` WHERE exists (select 1 from `orders` where `orders`.`user_id` = `mock_users`.`id` and `orders`.`status` = ?) [paid]
+}
+
+func ExampleFilter_invalidColumn() {
+	rules := []Rule{{Name: "name", Column: "name; drop table users", Opt: Eq}}
+	_, _, err := ExplainMap(rules, map[string]any{"name": "john"})
+	fmt.Println(err)
+	// Output: invalid filter column: "name; drop table users"
+}
+
+func ExampleFilter_quote() {
+	type UserFilter struct {
+		Name  string `json:"name" filter:"table:users"`
+		Order string `json:"order" filter:"opt:="` // 关键字作为列名
+	}
+	printSQL(Filter(UserFilter{Name: "john", Order: "1"}))
+	// Output: SELECT * FROM `mock_users` WHERE `users`.`name` = ? AND `order` = ? [john 1]
+}
+
+func ExampleFilter_escape() {
+	type UserFilter struct {
+		Name string `json:"name" filter:"opt:like"`
+		Code string `json:"code" filter:"opt:like;wildcard:true"` // 保留用户输入的通配符
+	}
+	query, params, _ := Explain(UserFilter{Name: "100%_!", Code: "A_%"})
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' AND code LIKE ? [%100!%!_!!% %A_%%]
+}
+
+func ExampleFilter_column() {
+	type UserFilter struct {
+		Keyword string `json:"q" filter:"column:name;opt:like"`
+	}
+	query, params, _ := Explain(UserFilter{Keyword: "jo"})
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' [%jo%]
+}
+
+func ExampleFilter_gormColumn() {
+	type UserFilter struct {
+		Age int `gorm:"column:user_age" filter:"opt:="`
+	}
+	query, params, _ := Explain(UserFilter{Age: 20})
+	fmt.Println(query, params)
+	// Output: user_age = ? [20]
+}
+
+func ExampleFilter_snakeCase() {
+	type OrderFilter struct {
+		OrderNo   string `filter:"opt:="`
+		UserID    int    `filter:"opt:="`
+		CreatedAt string `filter:"opt:>="`
+	}
+	query, params, _ := Explain(OrderFilter{OrderNo: "A1", UserID: 1, CreatedAt: "2024-01-01"})
+	fmt.Println(query, params)
+	// Output: order_no = ? AND user_id = ? AND created_at >= ? [A1 1 2024-01-01]
+}
+
+func ExampleSetTagKey() {
+	type UserFilter struct {
+		Name string `json:"name" filter:"opt:=" search:"opt:like"`
+	}
+	SetTagKey("search")
+	defer SetTagKey("filter")
+	query, params, _ := Explain(UserFilter{Name: "jo"})
+	fmt.Println(query, params)
+	// Output: name like ? escape '!' [%jo%]
+}
+
+func ExampleFilter_embedded() {
+	type Common struct {
+		Status int `json:"status" filter:"opt:="`
+	}
+	type UserFilter struct {
+		Common
+		Name string `json:"name" filter:"opt:like"`
+	}
+	query, params, _ := Explain(UserFilter{Common: Common{Status: 1}, Name: "jo"})
+	fmt.Println(query, params)
+	// Output: status = ? AND name like ? escape '!' [1 %jo%]
+}
+
+func ExampleFilter_sqlNull() {
+	type UserFilter struct {
+		Name   sql.NullString `json:"name" filter:"opt:="` // Valid 为 false 时忽略, 为 true 时零值也生成条件
+		Age    sql.NullInt64  `json:"age" filter:"opt:="`
+		Active sql.NullBool   `json:"active" filter:"opt:="`
+	}
+	query, params, _ := Explain(UserFilter{Age: sql.NullInt64{Valid: true}, Active: sql.NullBool{Valid: true}})
+	fmt.Println(query, params)
+	// Output: age = ? AND active = ? [0 false]
+}
+
+func ExampleFilter_time() {
+	type OrderFilter struct {
+		Since time.Time `json:"since" filter:"column:created_at;opt:>="`
+		Until string    `json:"until" filter:"column:created_at;opt:<=;type:time;layout:02/01/2006"`
+	}
+	query, params, _ := Explain(OrderFilter{Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Until: "31/01/2024"})
+	fmt.Println(query, params[0], params[1].(time.Time).Format(time.DateOnly)) // layout 解析的时间在本地时区
+	// Output: created_at >= ? AND created_at <= ? 2024-01-01 00:00:00 +0000 UTC 2024-01-31
+}
+
+func ExampleFilter_dateRangeTZ() {
+	type OrderFilter struct {
+		CreatedAt []string `json:"created_at" filter:"opt:date_range;tz:Asia/Shanghai"` // 上海时间的整天, 转换为 UTC 绑定
+	}
+	query, params, _ := Explain(OrderFilter{CreatedAt: []string{"2024-01-01", "2024-01-31"}})
+	fmt.Println(query, params)
+	// Output: created_at between ? and ? [2023-12-31 16:00:00 +0000 UTC 2024-01-31 15:59:59.999999999 +0000 UTC]
+}
+
+func ExampleFilter_dateRangeHalfOpen() {
+	type OrderFilter struct {
+		CreatedAt []string `json:"created_at" filter:"opt:date_range;half_open:true"`
+	}
+	query, params, _ := Explain(OrderFilter{CreatedAt: []string{"2024-01-01", "2024-01-31"}})
+	fmt.Println(query, params)
+	// Output: created_at >= ? AND created_at < ? [2024-01-01 00:00:00 2024-02-01 00:00:00]
+}
+
+func ExampleFilter_dateRangeOpen() {
+	type OrderFilter struct {
+		CreatedAt []string `json:"created_at" filter:"opt:date_range"`
+	}
+	query, params, _ := Explain(OrderFilter{CreatedAt: []string{"2024-01-01", ""}})
+	fmt.Println(query, params)
+	query, params, _ = Explain(OrderFilter{CreatedAt: []string{"", "2024-01-31"}})
+	fmt.Println(query, params)
+	// Output:
+	// created_at >= ? [2024-01-01 00:00:00]
+	// created_at <= ? [2024-01-31 23:59:59]
+}
+
+func ExampleFilter_columns() {
+	type UserFilter struct {
+		Keyword string `json:"q" filter:"columns:name,email,phone;opt:like"`
+	}
+	query, params, _ := Explain(UserFilter{Keyword: "jo"})
+	fmt.Println(query, params)
+	// Output: (name like ? escape '!' OR email like ? escape '!' OR phone like ? escape '!') [%jo% %jo% %jo%]
+}
+
+func ExampleSetTieBreaker() {
+	SetDefaultSort("-created_at")
+	SetTieBreaker("id")
+	defer SetDefaultSort("")
+	defer SetTieBreaker("")
+	printSQL(Sort("", "name"))
+	printSQL(Sort("name", "name"))
+	// Output:
+	// SELECT * FROM `mock_users` ORDER BY `created_at` DESC,`id` DESC []
+	// SELECT * FROM `mock_users` ORDER BY `name`,`id` []
+}
+
+func ExampleSetNamingStrategy() {
+	type OrderFilter struct {
+		OrderNo string `filter:"opt:="`
+	}
+	query, _, _ := Explain(OrderFilter{OrderNo: "A1"})
+	fmt.Println(query)
+	// 解析的规则按结构体类型缓存, 修改命名策略后重新解析
+	SetNamingStrategy(schema.NamingStrategy{NameReplacer: strings.NewReplacer("No", "Number")})
+	defer SetNamingStrategy(schema.NamingStrategy{})
+	query, _, _ = Explain(OrderFilter{OrderNo: "A1"})
+	fmt.Println(query)
+	// Output:
+	// order_no = ?
+	// order_number = ?
+}
+
+func ExampleFilter_nestedJoin() {
+	type ItemFilter struct {
+		SKU string `json:"sku" filter:"opt:starts_with;wildcard:true"`
+	}
+	type OrderFilter struct {
+		Status int        `json:"status" filter:"opt:="`
+		Item   ItemFilter `json:"item" filter:"table:order_items;join:order_id=id"`
+	}
+	type UserFilter struct {
+		Order OrderFilter `json:"order" filter:"table:orders;join:user_id=id"`
+	}
+	printSQL(Filter(UserFilter{Order: OrderFilter{Status: 1, Item: ItemFilter{SKU: "A_"}}}))
+	// Output: SELECT `mock_users`.`id`,`mock_users`.`name`,`mock_users`.`age` FROM `mock_users` join `orders` on `orders`.`user_id` = `mock_users`.`id` join `order_items` on `order_items`.`order_id` = `orders`.`id` WHERE `orders`.`status` = ? AND `order_items`.`sku` LIKE ? [1 A_%]
+}
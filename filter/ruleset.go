@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// UnmarshalJSON decodes a rule from JSON like {"name":"age","opt":">="}, numbers of its default and map
+// are decoded as integers if they are, like the default and map tag options
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	type plain Rule // 避免递归调用 UnmarshalJSON
+	var rule plain
+	if err := decodeJSON(data, &rule); err != nil {
+		return err
+	}
+	rule.Default = jsonNumber(rule.Default)
+	for k, v := range rule.Map {
+		rule.Map[k] = jsonNumber(v)
+	}
+	*r = Rule(rule)
+	return nil
+}
+
+// jsonNumber converts the json.Number of a decoded value, or of the elements of a decoded array,
+// to an integer or a float
+func jsonNumber(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		return tagValue(v.String())
+	case []any:
+		for i := range v {
+			v[i] = jsonNumber(v[i])
+		}
+	}
+	return v
+}
+
+// ParseRules decodes and checks a JSON array of rules for Search, MultiSearch, FilterMap and the other
+// rule based filters, e.g. rule sets stored in config files or per tenant in the database. Trusted and SQL
+// are not decoded, so stored rules cannot skip the name checks or use raw rules
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTag, err)
+	}
+	if err := ValidateRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
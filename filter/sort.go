@@ -0,0 +1,121 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	defaultSort []clause.OrderByColumn // 未指定排序时使用的排序
+	tieBreaker  string                 // 追加到每个排序的唯一列, 使分页结果稳定
+)
+
+// SetDefaultSort sets the sort like "-created_at" used when none is given, it panics on invalid sorts
+func SetDefaultSort(orderBy string) {
+	orders, err := parseSort(orderBy)
+	if err != nil {
+		panic(err)
+	}
+	defaultSort = orders
+}
+
+// SetTieBreaker sets a unique column like id appended to every sort not ordered by it yet,
+// it panics on invalid columns
+func SetTieBreaker(column string) {
+	if column != "" && !isIdentifier(column) {
+		panic(fmt.Errorf("%w: %q", ErrInvalidColumn, column))
+	}
+	tieBreaker = column
+}
+
+// Sort orders the query by a sort specification like "name desc,created_at" or "-name,created_at",
+// whose columns must be in allowed. Columns are ascending by default, invalid specifications are added
+// to db as errors. The default sort and the tie breaker are applied if they are set
+func Sort(orderBy string, allowed ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		orders, err := sortOrders(orderBy, allowed)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		for _, order := range orders {
+			db.Order(order)
+		}
+		return db
+	}
+}
+
+// sortOrders returns the order of a sort specification whose columns must be in allowed,
+// with the default sort for empty specifications and the tie breaker appended
+func sortOrders(orderBy string, allowed []string) ([]clause.OrderByColumn, error) {
+	orders, err := parseSort(orderBy)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if !contains(allowed, order.Column.Name) {
+			return nil, fmt.Errorf("%w: sorting by %q is not allowed", ErrInvalidColumn, order.Column.Name)
+		}
+	}
+	if len(orders) == 0 {
+		orders = append(orders, defaultSort...)
+	}
+
+	if tieBreaker != "" && len(orders) > 0 {
+		for _, order := range orders {
+			if order.Column.Name == tieBreaker {
+				return orders, nil
+			}
+		}
+		orders = append(orders, clause.OrderByColumn{Column: clause.Column{Name: tieBreaker}, Desc: orders[len(orders)-1].Desc})
+	}
+	return orders, nil
+}
+
+// parseSort parses a sort specification into the order of its columns
+func parseSort(orderBy string) ([]clause.OrderByColumn, error) {
+	var orders []clause.OrderByColumn
+	for _, term := range strings.Split(orderBy, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var desc bool
+		column, direction, _ := strings.Cut(term, " ")
+		switch {
+		case strings.HasPrefix(column, "-"):
+			column, desc = column[1:], true
+		case strings.HasPrefix(column, "+"):
+			column = column[1:]
+		}
+		switch strings.ToLower(strings.TrimSpace(direction)) {
+		case "":
+		case "asc":
+			desc = false
+		case "desc":
+			desc = true
+		default:
+			return nil, fmt.Errorf("%w: invalid sort direction %q", ErrInvalidValue, direction)
+		}
+
+		if !isIdentifier(column) {
+			return nil, fmt.Errorf("%w: sorting by %q is not allowed", ErrInvalidColumn, column)
+		}
+		orders = append(orders, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+	return orders, nil
+}
+
+// contains reports whether s is in list
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package filter
+
+import "sync"
+
+// FilterStats describes a filter added to a query, reported to the OnApply callbacks
+type FilterStats struct {
+	Source     string   // 条件的来源, 结构体类型如 api.UserFilter, 或 query、rsql、odata、mongo、jsonapi、graphql、map、multi_search
+	Conditions int      // 条件数量, 包含 RequireScope 的条件
+	Operators  []string // 条件的运算符, 不包含组合的条件, 如 RSQL 的表达式
+	Skipped    int      // 没有生成条件的字段或规则数量, 见 Diagnose
+}
+
+var (
+	applyMu    sync.RWMutex
+	applyFuncs []func(stats FilterStats)
+)
+
+// OnApply registers a callback called with the stats of every filter scope added to a query
+func OnApply(fn func(stats FilterStats)) {
+	applyMu.Lock()
+	defer applyMu.Unlock()
+	applyFuncs = append(applyFuncs, fn)
+}
+
+// reportStats calls the OnApply callbacks with the stats of res
+func reportStats(res result) {
+	applyMu.RLock()
+	funcs := applyFuncs
+	applyMu.RUnlock()
+	if len(funcs) == 0 {
+		return
+	}
+	stats := FilterStats{Source: res.source, Conditions: len(res.conditions), Skipped: len(res.skipped)}
+	for _, cond := range res.conditions {
+		if cond.opt != "" {
+			stats.Operators = append(stats.Operators, cond.opt)
+		}
+	}
+	for _, fn := range funcs {
+		fn(stats)
+	}
+}
@@ -0,0 +1,39 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// subqueryOperators are the SQL operators of the rules accepting a *gorm.DB subquery as the value
+var subqueryOperators = map[string]string{
+	Eq: "=", Neq: "<>", "neq": "<>", GT: ">", LT: "<", GTE: ">=", LTE: "<=", In: "IN", NotIn: "NOT IN",
+}
+
+// subquery returns the *gorm.DB of a subquery value, e.g. db.Model(&Order{}).Select("user_id")
+func subquery(rfVal reflect.Value) (*gorm.DB, bool) {
+	if !rfVal.IsValid() || !rfVal.CanInterface() {
+		return nil, false
+	}
+	sub, ok := rfVal.Interface().(*gorm.DB)
+	return sub, ok
+}
+
+// subqueryCondition returns the condition comparing col with the result of the subquery,
+// raw rules bind the subquery to the placeholders of their sql
+func subqueryCondition(rule Rule, col clause.Column, sub *gorm.DB) (clause.Expression, error) {
+	if rule.Opt == Raw {
+		if rule.SQL == "" {
+			return nil, fmt.Errorf("%w: raw rule requires sql", ErrInvalidTag)
+		}
+		return rawExpr(rule.SQL, sub), nil
+	}
+	op, ok := subqueryOperators[rule.Opt]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s rule does not accept a subquery", ErrInvalidValue, rule.Opt)
+	}
+	return clause.Expr{SQL: "? " + op + " (?)", Vars: []interface{}{col, sub}}, nil
+}
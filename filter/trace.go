@@ -0,0 +1,51 @@
+package filter
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// attributes of the trace spans annotated by SpanAnnotator
+const (
+	AttrColumns    = "db.filter.columns"    // []string, 条件的列, 如 users.name
+	AttrOperators  = "db.filter.operators"  // []string, 条件的运算符, 与列一一对应
+	AttrConditions = "db.filter.conditions" // int, 条件数量, 包含 RequireScope 的条件
+)
+
+// SpanAnnotator annotates the active trace span of ctx with the attributes of a filter, which name the columns
+// and operators of its conditions but never their values. With OpenTelemetry it could be
+//
+//	func(ctx context.Context, attrs map[string]any) {
+//		span := trace.SpanFromContext(ctx)
+//		span.SetAttributes(attribute.StringSlice(filter.AttrColumns, attrs[filter.AttrColumns].([]string)), ...)
+//	}
+type SpanAnnotator func(ctx context.Context, attrs map[string]any)
+
+var spanAnnotator SpanAnnotator // 为空时不标注
+
+// SetSpanAnnotator sets the annotator called with the context of every filter scope, nil disables it
+func SetSpanAnnotator(fn SpanAnnotator) {
+	spanAnnotator = fn
+}
+
+// annotateSpan calls the span annotator with the columns and operators of the conditions of res
+func annotateSpan(db *gorm.DB, res result) {
+	fn := spanAnnotator
+	if fn == nil || db.Statement.Context == nil {
+		return
+	}
+	columns := make([]string, 0, len(res.conditions))
+	operators := make([]string, 0, len(res.conditions))
+	for _, cond := range res.conditions {
+		if cond.column != "" { // 组合的条件, 如 RSQL 的表达式, 没有列
+			columns = append(columns, cond.column)
+			operators = append(operators, cond.opt)
+		}
+	}
+	fn(db.Statement.Context, map[string]any{
+		AttrColumns:    columns,
+		AttrOperators:  operators,
+		AttrConditions: len(res.conditions),
+	})
+}
@@ -0,0 +1,158 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TransformFunc transforms a field value before it is bound, e.g. lowercasing or normalizing phone numbers.
+// Returning nil skips the condition
+type TransformFunc func(v any) any
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]TransformFunc{
+		"trim":     stringTransform(strings.TrimSpace),
+		"collapse": stringTransform(func(s string) string { return strings.Join(strings.Fields(s), " ") }),
+		"lower":    stringTransform(strings.ToLower),
+		"upper":    stringTransform(strings.ToUpper),
+	}
+
+	defaultTransforms []string // 应用于所有规则的变换, 在规则自己的变换之前
+)
+
+// RegisterTransform registers a transform used by the transform tag option or Rule.Transform. The built-in
+// trim, collapse (trim and collapse inner white space), lower and upper apply to strings and string slices only
+func RegisterTransform(name string, fn TransformFunc) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	transforms[name] = fn
+}
+
+// SetDefaultTransforms sets the transforms applied to the values of every rule before their own,
+// it panics on unknown transforms
+func SetDefaultTransforms(names ...string) {
+	for _, name := range names {
+		if _, ok := lookupTransform(name); !ok {
+			panic(fmt.Errorf("%w: unknown transform %q", ErrInvalidTag, name))
+		}
+	}
+	defaultTransforms = names
+}
+
+// hasTransforms reports whether values of rule are transformed
+func hasTransforms(rule Rule) bool {
+	return len(rule.Transform) > 0 || len(defaultTransforms) > 0
+}
+
+// lookupTransform returns the transform registered with name
+func lookupTransform(name string) (TransformFunc, bool) {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	fn, ok := transforms[name]
+	return fn, ok
+}
+
+// stringTransform returns a transform applying fn to strings and the elements of string slices,
+// other values are returned as is
+func stringTransform(fn func(string) string) TransformFunc {
+	return func(v any) any {
+		switch v := v.(type) {
+		case string:
+			return fn(v)
+		case []string:
+			s := make([]string, len(v))
+			for i := range v {
+				s[i] = fn(v[i])
+			}
+			return s
+		}
+		return v
+	}
+}
+
+// transformValue applies the default transforms and then those of rule in order to the value,
+// which is invalid if a transform returns nil
+func transformValue(rule Rule, rfVal reflect.Value) (reflect.Value, error) {
+	for rfVal.Kind() == reflect.Ptr && !rfVal.IsNil() {
+		rfVal = rfVal.Elem()
+	}
+	if rfVal.Kind() == reflect.Ptr { // nil 指针
+		return rfVal, nil
+	}
+
+	v := rfVal.Interface()
+	if v == nil { // nil 接口值不变换
+		return rfVal, nil
+	}
+	for _, names := range [2][]string{defaultTransforms, rule.Transform} {
+		for _, name := range names {
+			fn, ok := lookupTransform(name)
+			if !ok {
+				return rfVal, fmt.Errorf("%w: unknown transform %q", ErrInvalidTag, name)
+			}
+			if v = fn(v); v == nil {
+				return reflect.Value{}, nil
+			}
+		}
+	}
+	return reflect.ValueOf(v), nil
+}
+
+// mapValue maps a string or the string elements of a slice with the map of rule,
+// values missing from the map are invalid
+func mapValue(rule Rule, rfVal reflect.Value) (reflect.Value, error) {
+	for rfVal.Kind() == reflect.Ptr && !rfVal.IsNil() {
+		rfVal = rfVal.Elem()
+	}
+	lookup := func(s string) (any, error) {
+		v, ok := rule.Map[s]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s: unknown value %q", ErrInvalidValue, rule.Name, s)
+		}
+		return v, nil
+	}
+
+	switch {
+	case rfVal.Kind() == reflect.String:
+		v, err := lookup(rfVal.String())
+		if err != nil {
+			return rfVal, err
+		}
+		if v == nil { // 映射为 nil 时仍为有效值
+			return reflect.ValueOf(&v).Elem(), nil
+		}
+		return reflect.ValueOf(v), nil
+	case rfVal.Kind() == reflect.Slice && rfVal.Type().Elem().Kind() == reflect.String,
+		rfVal.Kind() == reflect.Slice && rfVal.Type().Elem().Kind() == reflect.Interface: // []string 或默认值 []any
+		values := make([]any, rfVal.Len())
+		for i := range values {
+			s, ok := rfVal.Index(i).Interface().(string)
+			if !ok {
+				return rfVal, fmt.Errorf("%w: %s: map requires string values", ErrInvalidValue, rule.Name)
+			}
+			v, err := lookup(s)
+			if err != nil {
+				return rfVal, err
+			}
+			values[i] = v
+		}
+		return reflect.ValueOf(values), nil
+	}
+	return rfVal, nil
+}
+
+// tagValue returns the value of a tag option as an integer or a float if it is one, e.g. the values of map,
+// so they bind like the numeric columns they are compared with
+func tagValue(s string) any {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
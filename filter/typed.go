@@ -0,0 +1,52 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// TypedFilter is the filter of the struct type T, or a pointer to it, whose tags are parsed once by For
+type TypedFilter[T any] struct {
+	rules []fieldRule
+	ptr   bool // T 是否为结构体指针
+}
+
+// For returns the filter of the struct type T, or a pointer to it, parsing its filter tags once.
+// It panics on invalid tags and should be called during initialization
+func For[T any]() *TypedFilter[T] {
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	f := &TypedFilter[T]{ptr: rt.Kind() == reflect.Ptr}
+	if f.ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		panic(fmt.Errorf("%w: %s is not a struct", ErrInvalidValue, rt))
+	}
+	rules, err := std.parseFieldRules(rt)
+	if err != nil {
+		panic(err)
+	}
+	f.rules = rules
+	return f
+}
+
+// Apply applies the filter of value to db like FilterE, invalid values are added to db as errors
+func (f *TypedFilter[T]) Apply(db *gorm.DB, value T) *gorm.DB {
+	return db.Scopes(f.Scope(value))
+}
+
+// Scope returns the filter scope of value like FilterE, invalid values are added to db as errors
+func (f *TypedFilter[T]) Scope(value T) func(*gorm.DB) *gorm.DB {
+	return scope(func(db *gorm.DB) (result, error) {
+		rv := reflect.ValueOf(&value).Elem()
+		if f.ptr {
+			if rv.IsNil() {
+				return result{}, nil
+			}
+			rv = rv.Elem()
+		}
+		return buildRules(db, f.rules, rv)
+	}, true)
+}
@@ -0,0 +1,58 @@
+package filter
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// uuidArray reports whether rt is a 16 byte array, such as github.com/google/uuid.UUID
+func uuidArray(rt reflect.Type) bool {
+	return rt.Kind() == reflect.Array && rt.Len() == 16 && rt.Elem().Kind() == reflect.Uint8
+}
+
+// uuidValue returns the value of a 16 byte array field, or a slice of them, as bound to the query, and checks
+// the string values of the =, != and in operators of uuid rules. uuid.Nil is a zero value and already skipped
+func uuidValue(db *gorm.DB, rule Rule, rfVal reflect.Value) (reflect.Value, error) {
+	switch {
+	case !rfVal.IsValid():
+		return rfVal, nil
+	case uuidArray(rfVal.Type()):
+		return reflect.ValueOf(bindUUID(db, rfVal)), nil
+	case (rfVal.Kind() == reflect.Slice || rfVal.Kind() == reflect.Array) && uuidArray(rfVal.Type().Elem()):
+		values := make([]any, rfVal.Len())
+		for i := range values {
+			values[i] = bindUUID(db, rfVal.Index(i))
+		}
+		return reflect.ValueOf(values), nil
+	}
+	if rule.Type != TypeUUID {
+		return rfVal, nil
+	}
+	switch rule.Opt {
+	case Eq, Neq, "neq", In, NotIn:
+		if err := eachValue(validUUID)(rfVal.Interface()); err != nil {
+			return rfVal, fmt.Errorf("%w: %s: %v", ErrInvalidValue, rule.Name, err)
+		}
+	}
+	return rfVal, nil
+}
+
+// bindUUID returns the bound value of a 16 byte array: types implementing driver.Valuer like uuid.UUID
+// bind their own value, the others bind the text form on postgres, whose uuid columns accept it, and bytes
+// on other databases, which store them in binary columns
+func bindUUID(db *gorm.DB, rfVal reflect.Value) any {
+	if valuer, ok := rfVal.Interface().(driver.Valuer); ok {
+		return valuer
+	}
+	b := make([]byte, 16)
+	reflect.Copy(reflect.ValueOf(b), rfVal)
+	if dialect(db) != "postgres" {
+		return b
+	}
+	s := hex.EncodeToString(b)
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
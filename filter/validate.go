@@ -0,0 +1,131 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ValidatorFunc checks a field value before its condition is generated, e.g. rejecting negative page numbers
+// or malformed ids. The error is reported like an invalid value
+type ValidatorFunc func(v any) error
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]ValidatorFunc{
+		"uuid":         eachValue(validUUID),
+		"positive":     eachValue(numberValidator(func(f float64) bool { return f > 0 }, "positive")),
+		"non_negative": eachValue(numberValidator(func(f float64) bool { return f >= 0 }, "non-negative")),
+	}
+)
+
+// RegisterValidator registers a validator used by the validate tag option or Rule.Validators. The built-in
+// uuid, positive and non_negative check each element of slices
+func RegisterValidator(name string, fn ValidatorFunc) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[name] = fn
+}
+
+// lookupValidator returns the validator registered with name
+func lookupValidator(name string) (ValidatorFunc, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	fn, ok := validators[name]
+	return fn, ok
+}
+
+// validateValue runs the validators of rule and then rule.Validate on the value
+func validateValue(rule Rule, rfVal reflect.Value) error {
+	if len(rule.Validators) == 0 && rule.Validate == nil {
+		return nil
+	}
+	for rfVal.Kind() == reflect.Ptr && !rfVal.IsNil() {
+		rfVal = rfVal.Elem()
+	}
+	v := rfVal.Interface()
+	for _, name := range rule.Validators {
+		fn, ok := lookupValidator(name)
+		if !ok {
+			return fmt.Errorf("%w: unknown validator %q", ErrInvalidTag, name)
+		}
+		if err := fn(v); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidValue, rule.Name, err)
+		}
+	}
+	if rule.Validate != nil {
+		if err := rule.Validate(v); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidValue, rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// eachValue returns a validator applying fn to the value, or to each element of slices except []byte
+func eachValue(fn ValidatorFunc) ValidatorFunc {
+	return func(v any) error {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+			return fn(v)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := fn(rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// validUUID checks that v is a UUID like 123e4567-e89b-12d3-a456-426614174000, or a fmt.Stringer of one
+func validUUID(v any) error {
+	s, ok := v.(string)
+	if stringer, isStringer := v.(fmt.Stringer); !ok && isStringer {
+		s, ok = stringer.String(), true
+	}
+	if !ok {
+		return errors.New("uuid requires a string")
+	}
+	if len(s) != 36 {
+		return fmt.Errorf("malformed uuid %q", s)
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return fmt.Errorf("malformed uuid %q", s)
+			}
+		default:
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+				return fmt.Errorf("malformed uuid %q", s)
+			}
+		}
+	}
+	return nil
+}
+
+// numberValidator returns a validator checking numbers with ok, described as want in errors
+func numberValidator(ok func(float64) bool, want string) ValidatorFunc {
+	return func(v any) error {
+		var f float64
+		switch rv := reflect.ValueOf(v); rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f = float64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			f = rv.Float()
+		default:
+			r, isDecimal := decimalValue(rv) // decimal.Decimal 等
+			if !isDecimal {
+				return fmt.Errorf("%s requires a number", want)
+			}
+			f, _ = r.Float64()
+		}
+		if !ok(f) {
+			return fmt.Errorf("%v is not %s", v, want)
+		}
+		return nil
+	}
+}